@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Sn0rt/secret2es/pkg/converter"
+)
+
+// convertChanged converts the YAML files under the input dir changed in the
+// revision range or staged in the local git repository. Every file reports
+// its result on its own, the files without secrets are skipped silently.
+func convertChanged(c conversion, outputDir, revRange string, staged bool) error {
+	files, err := converter.ChangedFiles(c.inputPath, revRange, staged)
+	if err != nil {
+		return err
+	}
+	layered, err := c.resolver()
+	if err != nil {
+		return err
+	}
+	var resolver converter.Resolver
+	if layered != nil {
+		resolver = layered
+	}
+
+	output := fileOutput{root: c.inputPath, rootIsDir: true, dir: outputDir}
+	converted, failed := 0, 0
+	for _, file := range files {
+		result, warn, err := converter.ConvertSecretFile(file, c.storeType, c.storeName, c.creationPolicy, resolver, c.opts)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			failed++
+			continue
+		}
+		if warn != "" {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: warn: %s", file, warn)
+		}
+		if result == "" {
+			continue
+		}
+		outputPath, err := output.write(file, result)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			failed++
+			continue
+		}
+		if outputPath != "" {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: written %s\n", file, outputPath)
+		}
+		converted++
+	}
+	if layered != nil {
+		printOrigins("", layered)
+	}
+
+	printCacheStats(c.opts.Cache)
+	_, _ = fmt.Fprintf(os.Stderr, "converted %d of %d changed files\n", converted, len(files))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d changed files failed to convert", failed, len(files))
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sn0rt/secret2es/pkg/converter"
+)
+
+func checkCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check the committed external secrets are up to date with their corev1 secrets",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := conversionFlags(cmd)
+			if err != nil {
+				return err
+			}
+			expectedPath, err := cmd.Flags().GetString("expected")
+			if err != nil {
+				return err
+			}
+
+			committed, err := os.ReadFile(expectedPath)
+			if err != nil {
+				return fmt.Errorf("error reading committed output: %w", err)
+			}
+			output, err := c.convert()
+			if err != nil {
+				return err
+			}
+			drifts, err := converter.CompareOutput(committed, []byte(output))
+			if err != nil {
+				return err
+			}
+
+			for _, drift := range drifts {
+				fmt.Printf("--- %s (%s)\n+++ %s (%s)\n%s", drift.Object, expectedPath, drift.Object, c.inputPath, drift.Diff)
+			}
+			if len(drifts) > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d objects of %s drifted from %s, run es-gen to update them", len(drifts), expectedPath, c.inputPath)
+			}
+			_, _ = fmt.Fprintf(os.Stderr, "%s is up to date with %s\n", expectedPath, c.inputPath)
+			return nil
+		},
+	}
+
+	addConversionFlags(cmd)
+	cmd.Flags().StringP("expected", "e", "", "Path of the committed output of es-gen to compare with (required)")
+
+	if err := cmd.MarkFlagRequired("input"); err != nil {
+		return nil
+	}
+	if err := cmd.MarkFlagRequired("expected"); err != nil {
+		return nil
+	}
+	return cmd
+}
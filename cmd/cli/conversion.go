@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/spf13/cobra"
+
+	"github.com/Sn0rt/secret2es/pkg/converter"
+)
+
+// conversion holds the flags shared by the commands converting an input file.
+type conversion struct {
+	inputPath      string
+	storeType      string
+	storeName      string
+	creationPolicy esv1beta1.ExternalSecretCreationPolicy
+	resolve        bool
+	valuesFiles    []string
+	setValues      []string
+	opts           converter.ConvertOptions
+}
+
+// addConversionFlags registers the flags of the commands writing the
+// converted objects, the secret flags and those of the output.
+func addConversionFlags(cmd *cobra.Command) {
+	addSecretFlags(cmd)
+	cmd.Flags().Int("template-from-size", 0, "Move the multi-line template values larger than this many bytes to a ConfigMap referenced by templateFrom, 0 keeps them inline")
+	cmd.Flags().String("cache-dir", "", "Dir of the on-disk cache of the converted documents, unchanged documents are taken from it instead of converted again")
+	cmd.Flags().Bool("trace", false, "Annotate every generated object with its source file, document index and sha256 and the tool version")
+}
+
+// addSecretFlags registers the flags of the input file and how its secrets
+// are converted.
+func addSecretFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("input", "i", "", "Input path of corev1 secret file (required)")
+	cmd.Flags().StringP("storetype", "s", "SecretStore", "Store type (optional)")
+	cmd.Flags().StringP("storename", "n", "", "Store name (required)")
+	cmd.Flags().StringP("creation-policy", "c", "Owner", "Create policy, only Owner, Orphan")
+	addResolveFlags(cmd)
+	cmd.Flags().Bool("extract-paths", false, "Extract the whole vault path with a dataFrom.extract when a secret mirrors it, the secret gets every property of the path")
+	cmd.Flags().Bool("strict", false, "Fail on the angle brackets that look like a placeholder but are not one, instead of leaving them as literal text")
+}
+
+// addResolveFlags registers the flags resolving the <% ENV %> placeholders.
+func addResolveFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("resolve", "r", false, "Resolve the <% ENV %> from env")
+	cmd.Flags().StringArray("values", nil, "Values file (.env, .yaml or .json) to resolve the <% ENV %> from, later files take precedence (implies --resolve)")
+	cmd.Flags().StringArray("set", nil, "Set a KEY=VALUE to resolve the <% ENV %>, takes precedence over values files and env (implies --resolve)")
+}
+
+func conversionFlags(cmd *cobra.Command) (conversion, error) {
+	c, err := secretFlags(cmd)
+	if err != nil {
+		return c, err
+	}
+	if c.opts.TemplateFromSize, err = cmd.Flags().GetInt("template-from-size"); err != nil {
+		return c, err
+	}
+	if c.opts.Trace, err = cmd.Flags().GetBool("trace"); err != nil {
+		return c, err
+	}
+	c.opts.ToolVersion = version
+	cacheDir, err := cmd.Flags().GetString("cache-dir")
+	if err != nil {
+		return c, err
+	}
+	if cacheDir != "" {
+		if c.opts.Cache, err = converter.NewConvertCache(cacheDir); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func secretFlags(cmd *cobra.Command) (conversion, error) {
+	var c conversion
+	var err error
+	if c.inputPath, err = cmd.Flags().GetString("input"); err != nil {
+		return c, err
+	}
+	if c.storeType, err = cmd.Flags().GetString("storetype"); err != nil {
+		return c, err
+	}
+	if c.storeName, err = cmd.Flags().GetString("storename"); err != nil {
+		return c, err
+	}
+	if c.storeName == "" {
+		return c, fmt.Errorf("store name is required")
+	}
+	creationPolicy, err := cmd.Flags().GetString("creation-policy")
+	if err != nil {
+		return c, err
+	}
+	if creationPolicy == "" {
+		return c, fmt.Errorf("creation policy is required")
+	}
+	c.creationPolicy = esv1beta1.ExternalSecretCreationPolicy(creationPolicy)
+	if err = c.resolveFlags(cmd); err != nil {
+		return c, err
+	}
+	if c.opts.ExtractPaths, err = cmd.Flags().GetBool("extract-paths"); err != nil {
+		return c, err
+	}
+	if c.opts.Strict, err = cmd.Flags().GetBool("strict"); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+func (c *conversion) resolveFlags(cmd *cobra.Command) error {
+	var err error
+	if c.resolve, err = cmd.Flags().GetBool("resolve"); err != nil {
+		return err
+	}
+	if c.valuesFiles, err = cmd.Flags().GetStringArray("values"); err != nil {
+		return err
+	}
+	c.setValues, err = cmd.Flags().GetStringArray("set")
+	return err
+}
+
+// resolver returns the layered resolver of the values, nil when the
+// <% ENV %> placeholders are not resolved.
+func (c conversion) resolver() (*converter.LayeredResolver, error) {
+	if !c.resolve && len(c.valuesFiles) == 0 && len(c.setValues) == 0 {
+		return nil, nil
+	}
+	setSource, fileSources, err := valueSources(c.valuesFiles, c.setValues)
+	if err != nil {
+		return nil, err
+	}
+	return layeredResolver(setSource, fileSources), nil
+}
+
+// convert converts the input file, printing where the values were resolved
+// from and the warnings.
+func (c conversion) convert() (string, error) {
+	layered, err := c.resolver()
+	if err != nil {
+		return "", err
+	}
+	var resolver converter.Resolver
+	if layered != nil {
+		resolver = layered
+	}
+
+	output, warn, err := converter.ConvertSecretFile(c.inputPath, c.storeType, c.storeName, c.creationPolicy, resolver, c.opts)
+	if layered != nil {
+		printOrigins("", layered)
+	}
+	if err != nil {
+		return "", err
+	}
+	printWarn(warn)
+	printCacheStats(c.opts.Cache)
+	return output, nil
+}
+
+func printCacheStats(cache *converter.ConvertCache) {
+	if cache != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "cache: %d documents from cache, %d converted\n", cache.Hits, cache.Misses)
+	}
+}
+
+func printWarn(warn string) {
+	if warn != "" {
+		_, _ = fmt.Fprintf(os.Stderr, "warn: %s", warn)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sn0rt/secret2es/pkg/converter"
+)
+
+func explainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain",
+		Short: "Explain how every key of the corev1 secrets is converted",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := secretFlags(cmd)
+			if err != nil {
+				return err
+			}
+			layered, err := c.resolver()
+			if err != nil {
+				return err
+			}
+			var resolver converter.Resolver
+			if layered != nil {
+				resolver = layered
+			}
+
+			explanations, err := converter.ExplainSecretFile(c.inputPath, c.storeType, c.storeName, c.creationPolicy, resolver, c.opts)
+			if layered != nil {
+				printOrigins("", layered)
+			}
+			if err != nil {
+				return err
+			}
+
+			open, close := "«", "»"
+			if isTerminal(os.Stdout) {
+				open, close = "\033[1;33m", "\033[0m"
+			}
+			for i, explanation := range explanations {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Print(converter.FormatExplanation(explanation, open, close))
+			}
+			return nil
+		},
+	}
+
+	addSecretFlags(cmd)
+
+	if err := cmd.MarkFlagRequired("input"); err != nil {
+		return nil
+	}
+	return cmd
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
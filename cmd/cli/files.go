@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// fileOutput prints the output of every input file under a header, or writes
+// it to dir at the path of the input file relative to the input dir.
+type fileOutput struct {
+	root      string
+	rootIsDir bool
+	dir       string
+}
+
+// write returns where the output was written, empty when printed.
+func (o fileOutput) write(file, output string) (string, error) {
+	if o.dir == "" {
+		fmt.Printf("# %s\n%s", file, output)
+		return "", nil
+	}
+	outputPath := filepath.Join(o.dir, filepath.Base(file))
+	if o.rootIsDir {
+		rel, err := filepath.Rel(o.root, file)
+		if err != nil {
+			return "", err
+		}
+		outputPath = filepath.Join(o.dir, rel)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+		return "", err
+	}
+	return outputPath, os.WriteFile(outputPath, []byte(output), 0o644)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sn0rt/secret2es/pkg/converter"
+)
+
+func inventoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: "List every vault path and property the corev1 secrets read",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var c conversion
+			var err error
+			if c.inputPath, err = cmd.Flags().GetString("input"); err != nil {
+				return err
+			}
+			if err = c.resolveFlags(cmd); err != nil {
+				return err
+			}
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+			if err := converter.VerifyInventoryFormat(format); err != nil {
+				return err
+			}
+			byPath, err := cmd.Flags().GetBool("by-path")
+			if err != nil {
+				return err
+			}
+			var filter converter.InventoryFilter
+			for flag, pattern := range map[string]*string{
+				"namespace": &filter.Namespace,
+				"secret":    &filter.Secret,
+				"path":      &filter.Path,
+				"property":  &filter.Property,
+			} {
+				if *pattern, err = cmd.Flags().GetString(flag); err != nil {
+					return err
+				}
+			}
+			if err := filter.Verify(); err != nil {
+				return err
+			}
+
+			entries, err := c.inventory()
+			if err != nil {
+				return err
+			}
+			entries = converter.FilterInventory(entries, filter)
+			var output string
+			if byPath {
+				output, err = converter.FormatInventoryIndex(converter.InventoryIndex(entries), format)
+			} else {
+				output, err = converter.FormatInventory(entries, format)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Print(output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("input", "i", "", "Input path of corev1 secret file or of a dir of them (required)")
+	addResolveFlags(cmd)
+	cmd.Flags().StringP("format", "f", converter.InventoryFormatCSV, "Output format: "+strings.Join(converter.InventoryFormats, ", "))
+	cmd.Flags().Bool("by-path", false, "List the secrets reading every vault path instead of every property read")
+	cmd.Flags().String("namespace", "", "Only list the secrets of the namespaces matching this pattern")
+	cmd.Flags().String("secret", "", "Only list the secrets whose name matches this pattern")
+	cmd.Flags().String("path", "", "Only list the vault paths, relative to the mount, matching this pattern")
+	cmd.Flags().String("property", "", "Only list the properties matching this pattern")
+
+	if err := cmd.MarkFlagRequired("input"); err != nil {
+		return nil
+	}
+	return cmd
+}
+
+// inventory returns the vault properties read by the secrets of the input
+// file or dir.
+func (c conversion) inventory() ([]converter.InventoryEntry, error) {
+	layered, err := c.resolver()
+	if err != nil {
+		return nil, err
+	}
+	var resolver converter.Resolver
+	if layered != nil {
+		resolver = layered
+	}
+	files, err := inputFiles(c.inputPath)
+	if err != nil {
+		return nil, err
+	}
+	var entries []converter.InventoryEntry
+	for _, file := range files {
+		fileEntries, err := converter.InventoryFile(file, resolver)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		entries = append(entries, fileEntries...)
+	}
+	if layered != nil {
+		printOrigins("", layered)
+	}
+	return entries, nil
+}
+
+// inputFiles returns the input file or the YAML files of the input dir.
+func inputFiles(input string) ([]string, error) {
+	info, err := os.Stat(input)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{input}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(input, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() && isYAMLFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
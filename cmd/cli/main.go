@@ -1,9 +1,11 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 
@@ -18,6 +20,15 @@ var (
 func main() {
 	if err := setupAndExecute(); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		var missingErr *converter.MissingValuesError
+		var sourceErr *converter.SourceError
+		if errors.As(err, &missingErr) {
+			for _, occurrence := range missingErr.Occurrences {
+				_, _ = fmt.Fprint(os.Stderr, occurrence.Snippet())
+			}
+		} else if errors.As(err, &sourceErr) {
+			_, _ = fmt.Fprint(os.Stderr, sourceErr.Snippet())
+		}
 		os.Exit(1)
 	}
 }
@@ -29,6 +40,10 @@ func setupAndExecute() error {
 	}
 
 	rootCmd.AddCommand(extSecretGenCmd())
+	rootCmd.AddCommand(checkCmd())
+	rootCmd.AddCommand(explainCmd())
+	rootCmd.AddCommand(inventoryCmd())
+	rootCmd.AddCommand(policyCmd())
 	rootCmd.AddCommand(versionCmd())
 
 	return rootCmd.Execute()
@@ -39,46 +54,114 @@ func extSecretGenCmd() *cobra.Command {
 		Use:   "es-gen",
 		Short: "Generate external secrets from corev1 secrets",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			inputPath, err := cmd.Flags().GetString("input")
+			c, err := conversionFlags(cmd)
 			if err != nil {
 				return err
 			}
-			storeType, err := cmd.Flags().GetString("storetype")
+			matrixFile, err := cmd.Flags().GetString("matrix")
 			if err != nil {
 				return err
 			}
-			storeName, err := cmd.Flags().GetString("storename")
+			outputDir, err := cmd.Flags().GetString("output-dir")
 			if err != nil {
 				return err
 			}
-			if storeName == "" {
-				return fmt.Errorf("store name is required")
+			format, err := cmd.Flags().GetString("format")
+			if err != nil {
+				return err
+			}
+			if err := converter.VerifyOutputFormat(format); err != nil {
+				return err
 			}
-			creationPolicy, err := cmd.Flags().GetString("creation-policy")
+			c.opts.Format = format
+
+			helm, err := helmFlags(cmd)
 			if err != nil {
 				return err
 			}
-			if creationPolicy == "" {
-				return fmt.Errorf("creation policy is required")
+
+			kustomizeDir, err := cmd.Flags().GetString("kustomize")
+			if err != nil {
+				return err
 			}
-			resolve, err := cmd.Flags().GetBool("resolve")
+			overlaysFile, err := cmd.Flags().GetString("overlays")
 			if err != nil {
 				return err
 			}
+			if overlaysFile != "" && kustomizeDir == "" {
+				return fmt.Errorf("kustomize dir is required with overlays")
+			}
+			if kustomizeDir != "" && (matrixFile != "" || helm.enabled) {
+				return fmt.Errorf("kustomize is not supported with matrix or helm")
+			}
+			if format != converter.OutputFormatYAML && (kustomizeDir != "" || helm.enabled) {
+				return fmt.Errorf("format %s is not supported with kustomize or helm", format)
+			}
+
+			watch, err := cmd.Flags().GetBool("watch")
+			if err != nil {
+				return err
+			}
+			if watch {
+				if matrixFile != "" || kustomizeDir != "" || helm.enabled {
+					return fmt.Errorf("watch is not supported with matrix, kustomize or helm")
+				}
+				return watchInputs(c, outputDir)
+			}
 
-			err = converter.ConvertSecret(inputPath, storeType, storeName, esv1beta1.ExternalSecretCreationPolicy(creationPolicy), resolve)
+			gitRange, err := cmd.Flags().GetString("git-range")
 			if err != nil {
 				return err
 			}
-			return nil
+			gitStaged, err := cmd.Flags().GetBool("git-staged")
+			if err != nil {
+				return err
+			}
+			if gitRange != "" || gitStaged {
+				if matrixFile != "" || kustomizeDir != "" || helm.enabled {
+					return fmt.Errorf("git changes are not supported with matrix, kustomize or helm")
+				}
+				return convertChanged(c, outputDir, gitRange, gitStaged)
+			}
+
+			if matrixFile != "" {
+				if outputDir == "" {
+					return fmt.Errorf("output dir is required with matrix")
+				}
+				if helm.chartDir != "" || helm.opts.ValuesFromEnv {
+					return fmt.Errorf("helm values and chart are not supported with matrix, its outputs are resolved")
+				}
+				setSource, fileSources, err := valueSources(c.valuesFiles, c.setValues)
+				if err != nil {
+					return err
+				}
+				return convertMatrix(c.inputPath, c.storeType, c.storeName, c.creationPolicy,
+					matrixFile, outputDir, setSource, fileSources, c.opts, helm)
+			}
+
+			output, err := c.convert()
+			if err != nil {
+				return err
+			}
+			if kustomizeDir != "" {
+				return writeKustomize(kustomizeDir, overlaysFile, c.inputPath, output)
+			}
+			return helm.write(output, c.inputPath, c.storeType, c.storeName)
 		},
 	}
 
-	cmd.Flags().StringP("input", "i", "", "Input path of corev1 secret file (required)")
-	cmd.Flags().StringP("storetype", "s", "SecretStore", "Store type (optional)")
-	cmd.Flags().StringP("storename", "n", "", "Store name (required)")
-	cmd.Flags().StringP("creation-policy", "c", "Owner", "Create policy, only Owner, Orphan")
-	cmd.Flags().BoolP("resolve", "r", false, "Resolve the <% ENV %> from env")
+	addConversionFlags(cmd)
+	cmd.Flags().Bool("helm", false, "Escape the ESO template expressions so the output can be shipped in a Helm chart")
+	cmd.Flags().Bool("helm-values", false, "Turn the unresolved <% ENV %> into {{ .Values.env }} (implies --helm)")
+	cmd.Flags().String("helm-chart", "", "Write a minimal Helm chart around the output to this dir (implies --helm)")
+	cmd.Flags().String("kustomize", "", "Write a kustomize base with the output and the overlays of --overlays to this dir")
+	cmd.Flags().String("overlays", "", "Overlays file mapping clusters to their store name, kind and refresh interval (requires --kustomize)")
+	cmd.Flags().String("matrix", "", "Matrix file mapping environments to their <% ENV %> values, writes one resolved output per environment (requires --output-dir)")
+	cmd.Flags().StringP("output-dir", "o", "", "Output dir of the per environment ExternalSecrets of --matrix, or of the per file ExternalSecrets of --watch and --git-range")
+	cmd.Flags().String("git-range", "", "Only convert the YAML files of the input dir changed in this revision range of the local git repository, such as main...HEAD")
+	cmd.Flags().Bool("git-staged", false, "Only convert the YAML files of the input dir staged in the local git repository, for pre-commit hooks")
+	cmd.Flags().Bool("watch", false, "Convert the changed documents of the input file, or of the YAML files of the input dir, on every save until interrupted")
+	cmd.Flags().StringP("format", "f", converter.OutputFormatYAML, "Output format, only yaml, json, ndjson (one object per line) and list (a single v1 List)")
 
 	err := cmd.MarkFlagRequired("input")
 	if err != nil {
@@ -88,6 +171,145 @@ func extSecretGenCmd() *cobra.Command {
 	return cmd
 }
 
+// valueSources loads the --set flags and the values files, the last values
+// file first.
+func valueSources(valuesFiles, setValues []string) (converter.ValueSource, []converter.ValueSource, error) {
+	setSource, err := converter.ParseSetValues(setValues)
+	if err != nil {
+		return converter.ValueSource{}, nil, err
+	}
+	var fileSources []converter.ValueSource
+	for idx := len(valuesFiles) - 1; idx >= 0; idx-- {
+		fileSource, err := converter.LoadValuesFile(valuesFiles[idx])
+		if err != nil {
+			return converter.ValueSource{}, nil, err
+		}
+		fileSources = append(fileSources, fileSource)
+	}
+	return setSource, fileSources, nil
+}
+
+// layeredResolver layers the values by precedence: --set flags, then the
+// matrix environment if any, then values files, then the environment.
+func layeredResolver(setSource converter.ValueSource, fileSources []converter.ValueSource, matrix ...converter.ValueSource) *converter.LayeredResolver {
+	sources := append([]converter.ValueSource{setSource}, matrix...)
+	sources = append(sources, fileSources...)
+	sources = append(sources, converter.EnvironmentValues())
+	return converter.NewLayeredResolver(sources...)
+}
+
+func printOrigins(environment string, resolver *converter.LayeredResolver) {
+	for _, origin := range resolver.Origins() {
+		if environment == "" {
+			_, _ = fmt.Fprintf(os.Stderr, "resolved %s from %s\n", origin.Name, origin.Source)
+		} else {
+			_, _ = fmt.Fprintf(os.Stderr, "[%s] resolved %s from %s\n", environment, origin.Name, origin.Source)
+		}
+	}
+}
+
+// convertMatrix writes the fully resolved ExternalSecrets of every environment
+// of the matrix file to its own directory under outputDir.
+func convertMatrix(inputPath, storeType, storeName string, creationPolicy esv1beta1.ExternalSecretCreationPolicy,
+	matrixFile, outputDir string, setSource converter.ValueSource, fileSources []converter.ValueSource, opts converter.ConvertOptions, helm helmOutput) error {
+	environments, err := converter.LoadMatrixFile(matrixFile)
+	if err != nil {
+		return err
+	}
+
+	for _, environment := range environments {
+		resolver := layeredResolver(setSource, fileSources, environment.Values)
+		output, warn, err := converter.ConvertSecretFile(inputPath, storeType, storeName, creationPolicy, resolver, opts)
+		printOrigins(environment.Name, resolver)
+		if err != nil {
+			return fmt.Errorf("environment %s: %w", environment.Name, err)
+		}
+		if warn != "" {
+			_, _ = fmt.Fprintf(os.Stderr, "[%s] warn: %s", environment.Name, warn)
+		}
+
+		if helm.enabled {
+			// only escaped, the output references no values without --helm-values
+			output, _ = converter.HelmTemplate(output, helm.opts)
+		}
+
+		outputPath := converter.MatrixOutputPath(outputDir, environment.Name, inputPath)
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(outputPath, []byte(output), 0o644); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "[%s] written %s\n", environment.Name, outputPath)
+	}
+	return nil
+}
+
+// writeKustomize writes the output as the base of a kustomize layout with an
+// overlay per cluster of the overlays file.
+func writeKustomize(dir, overlaysFile, inputPath, output string) error {
+	var overlays []converter.KustomizeOverlay
+	if overlaysFile != "" {
+		var err error
+		if overlays, err = converter.LoadKustomizeOverlays(overlaysFile); err != nil {
+			return err
+		}
+	}
+	if err := converter.WriteKustomize(dir, map[string]string{filepath.Base(inputPath): output}, overlays); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "written kustomize base and %d overlays to %s\n", len(overlays), dir)
+	return nil
+}
+
+// helmOutput is how es-gen emits its output for Helm.
+type helmOutput struct {
+	enabled  bool
+	opts     converter.HelmOptions
+	chartDir string
+}
+
+func helmFlags(cmd *cobra.Command) (helmOutput, error) {
+	var helm helmOutput
+	var err error
+	if helm.enabled, err = cmd.Flags().GetBool("helm"); err != nil {
+		return helm, err
+	}
+	if helm.opts.ValuesFromEnv, err = cmd.Flags().GetBool("helm-values"); err != nil {
+		return helm, err
+	}
+	if helm.chartDir, err = cmd.Flags().GetString("helm-chart"); err != nil {
+		return helm, err
+	}
+	helm.opts.StoreFromValues = helm.chartDir != ""
+	helm.enabled = helm.enabled || helm.opts.ValuesFromEnv || helm.opts.StoreFromValues
+	return helm, nil
+}
+
+// write prints the output, escaped for Helm if enabled, or writes the chart.
+func (h helmOutput) write(output, inputPath, storeType, storeName string) error {
+	if !h.enabled {
+		if output != "" {
+			fmt.Println(output)
+		}
+		return nil
+	}
+
+	template, values := converter.HelmTemplate(output, h.opts)
+	if h.chartDir == "" {
+		if template != "" {
+			fmt.Println(template)
+		}
+		return nil
+	}
+	templates := map[string]string{filepath.Base(inputPath): template}
+	if err := converter.WriteHelmChart(h.chartDir, templates, storeType, storeName, values); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "written helm chart %s\n", h.chartDir)
+	return nil
+}
+
 func versionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "version",
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Sn0rt/secret2es/pkg/converter"
+)
+
+func policyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Generate the Vault ACL policies reading exactly the vault paths of the corev1 secrets",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var c conversion
+			var err error
+			if c.inputPath, err = cmd.Flags().GetString("input"); err != nil {
+				return err
+			}
+			if c.storeName, err = cmd.Flags().GetString("storename"); err != nil {
+				return err
+			}
+			if c.storeName == "" {
+				return fmt.Errorf("store name is required")
+			}
+			if err = c.resolveFlags(cmd); err != nil {
+				return err
+			}
+			groupBy, err := cmd.Flags().GetString("group-by")
+			if err != nil {
+				return err
+			}
+			if err := converter.VerifyPolicyGroup(groupBy); err != nil {
+				return err
+			}
+			outputDir, err := cmd.Flags().GetString("output-dir")
+			if err != nil {
+				return err
+			}
+
+			entries, err := c.inventory()
+			if err != nil {
+				return err
+			}
+			policies, err := converter.VaultPolicies(entries, c.storeName, groupBy)
+			if err != nil {
+				return err
+			}
+			for i, policy := range policies {
+				if outputDir == "" {
+					if i > 0 {
+						fmt.Println()
+					}
+					fmt.Print(policy.HCL())
+					continue
+				}
+				if err := os.MkdirAll(outputDir, 0o755); err != nil {
+					return err
+				}
+				outputPath := filepath.Join(outputDir, policy.Name+".hcl")
+				if err := os.WriteFile(outputPath, []byte(policy.HCL()), 0o644); err != nil {
+					return err
+				}
+				_, _ = fmt.Fprintf(os.Stderr, "written %s\n", outputPath)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("input", "i", "", "Input path of corev1 secret file or of a dir of them (required)")
+	cmd.Flags().StringP("storename", "n", "", "Store name (required)")
+	addResolveFlags(cmd)
+	cmd.Flags().String("group-by", converter.PolicyGroupStore, "Group the vault paths into a policy per "+strings.Join(converter.PolicyGroups, " or "))
+	cmd.Flags().StringP("output-dir", "o", "", "Dir to write every policy to as <name>.hcl instead of printing them")
+
+	if err := cmd.MarkFlagRequired("input"); err != nil {
+		return nil
+	}
+	return cmd
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/Sn0rt/secret2es/pkg/converter"
+)
+
+// watchDebounce collects the events of a save before converting.
+const watchDebounce = 100 * time.Millisecond
+
+// watcher converts the input files of a file or directory again on every
+// change, reporting every file on its own instead of stopping on an error.
+type watcher struct {
+	root      string
+	rootIsDir bool
+	output    fileOutput
+	converter *converter.IncrementalConverter
+}
+
+// watchInputs converts the input file or the YAML files of the input
+// directory, then the changed ones on save until interrupted.
+func watchInputs(c conversion, outputDir string) error {
+	info, err := os.Stat(c.inputPath)
+	if err != nil {
+		return err
+	}
+	layered, err := c.resolver()
+	if err != nil {
+		return err
+	}
+	var resolver converter.Resolver
+	if layered != nil {
+		resolver = layered
+	}
+	incremental, err := converter.NewIncrementalConverter(c.storeType, c.storeName, c.creationPolicy, resolver, c.opts)
+	if err != nil {
+		return err
+	}
+	root := filepath.Clean(c.inputPath)
+	w := &watcher{
+		root:      root,
+		rootIsDir: info.IsDir(),
+		output:    fileOutput{root: root, rootIsDir: info.IsDir(), dir: outputDir},
+		converter: incremental,
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = fsWatcher.Close() }()
+
+	files, err := w.files(fsWatcher)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		w.convert(file)
+	}
+	if layered != nil {
+		printOrigins("", layered)
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "watching %s, press Ctrl+C to stop\n", w.root)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	pending := make(map[string]bool)
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-fsWatcher.Errors:
+			_, _ = fmt.Fprintf(os.Stderr, "watch error: %v\n", err)
+		case event := <-fsWatcher.Events:
+			if event.Has(fsnotify.Create) && w.rootIsDir {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.add(fsWatcher, event.Name); err != nil {
+						_, _ = fmt.Fprintf(os.Stderr, "watch error: %v\n", err)
+					}
+					continue
+				}
+			}
+			if w.watched(event.Name) {
+				pending[event.Name] = true
+				debounce.Reset(watchDebounce)
+			}
+		case <-debounce.C:
+			for _, file := range sortedFiles(pending) {
+				if _, err := os.Stat(file); err != nil {
+					w.converter.Forget(file)
+					_, _ = fmt.Fprintf(os.Stderr, "[%s] %s: removed\n", now(), file)
+					continue
+				}
+				w.convert(file)
+			}
+			pending = make(map[string]bool)
+		}
+	}
+}
+
+// files returns the input files and watches their directories.
+func (w *watcher) files(fsWatcher *fsnotify.Watcher) ([]string, error) {
+	if !w.rootIsDir {
+		// editors often save by renaming, so the directory is watched
+		return []string{w.root}, fsWatcher.Add(filepath.Dir(w.root))
+	}
+	var files []string
+	err := filepath.WalkDir(w.root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return fsWatcher.Add(path)
+		}
+		if isYAMLFile(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// add watches a directory created under the input directory and converts
+// the files already in it.
+func (w *watcher) add(fsWatcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return fsWatcher.Add(path)
+		}
+		if isYAMLFile(path) {
+			w.convert(path)
+		}
+		return nil
+	})
+}
+
+func (w *watcher) watched(path string) bool {
+	if !w.rootIsDir {
+		return filepath.Clean(path) == w.root
+	}
+	return isYAMLFile(path)
+}
+
+// convert converts file and prints or writes its output, errors are only
+// reported.
+func (w *watcher) convert(file string) {
+	output, warn, converted, err := w.converter.ConvertFile(file)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[%s] %s: %v\n", now(), file, err)
+		return
+	}
+	if warn != "" {
+		_, _ = fmt.Fprintf(os.Stderr, "[%s] %s: warn: %s", now(), file, warn)
+	}
+
+	outputPath, err := w.output.write(file, output)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "[%s] %s: %v\n", now(), file, err)
+		return
+	}
+	if outputPath == "" {
+		_, _ = fmt.Fprintf(os.Stderr, "[%s] %s: converted %d changed secrets\n", now(), file, converted)
+	} else {
+		_, _ = fmt.Fprintf(os.Stderr, "[%s] %s: converted %d changed secrets, written %s\n", now(), file, converted, outputPath)
+	}
+}
+
+func isYAMLFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func sortedFiles(files map[string]bool) []string {
+	sorted := make([]string, 0, len(files))
+	for file := range files {
+		sorted = append(sorted, file)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func now() string {
+	return time.Now().Format("15:04:05")
+}
@@ -0,0 +1,197 @@
+package converter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+// cacheFormat changes whenever the cached entries or their keys do.
+const cacheFormat = 2
+
+// buildVersion identifies the sources of a binary built without a version,
+// empty when they are unknown, e.g. a go build of changed sources.
+var buildVersion = readBuildVersion()
+
+func readBuildVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	settings := make(map[string]string)
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+	if revision := settings["vcs.revision"]; revision != "" && settings["vcs.modified"] != "true" {
+		return revision
+	}
+	if info.Main.Sum != "" {
+		return info.Main.Version + " " + info.Main.Sum
+	}
+	return ""
+}
+
+// ConvertCache keeps the objects converted from every document on disk, so
+// unchanged documents are neither parsed nor converted again. An entry is
+// keyed by the document as written and where it is in the input, the
+// conversion settings, the tool version and the values of its <% VAR %>
+// placeholders. Without a tool version the build is used, nothing is cached
+// when that is unknown either.
+//
+// A hit skips parsing the document, the strict check, the check of the
+// generated templates and the schema validation, so the cached conversion
+// stays correct only while the key covers every input of the conversion.
+type ConvertCache struct {
+	dir string
+	// Hits and Misses count the documents taken from and added to the cache.
+	Hits   int
+	Misses int
+}
+
+// cacheEntry is the conversion of a document, the objects are in the form of
+// resourceMap and YAML is their YAML output.
+type cacheEntry struct {
+	Resources []map[string]interface{} `json:"resources"`
+	YAML      string                   `json:"yaml"`
+	Warn      string                   `json:"warn,omitempty"`
+}
+
+// cacheKey is everything the conversion of a document depends on.
+type cacheKey struct {
+	Format         int                                    `json:"format"`
+	ToolVersion    string                                 `json:"toolVersion"`
+	File           string                                 `json:"file"`
+	Index          int                                    `json:"index"`
+	Line           int                                    `json:"line"`
+	Text           string                                 `json:"text"`
+	StoreType      string                                 `json:"storeType"`
+	StoreName      string                                 `json:"storeName"`
+	CreationPolicy esv1beta1.ExternalSecretCreationPolicy `json:"creationPolicy"`
+	ExtractPaths   bool                                   `json:"extractPaths"`
+	Strict         bool                                   `json:"strict"`
+	// TemplateFromSize and Trace are the options changing the objects.
+	TemplateFromSize int  `json:"templateFromSize"`
+	Trace            bool `json:"trace"`
+	// Values are the values of the <% VAR %> placeholders, absent when unset.
+	Resolve bool              `json:"resolve"`
+	Values  map[string]string `json:"values,omitempty"`
+}
+
+// NewConvertCache returns the cache stored in dir, creating it if needed.
+func NewConvertCache(dir string) (*ConvertCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating cache dir: %w", err)
+	}
+	return &ConvertCache{dir: dir}, nil
+}
+
+// key returns the key of a document, false when the tool version is unknown
+// and the document must not be cached. As a hit skips the checks of the
+// conversion, the key holds every input they depend on, Strict and the values
+// of the <% VAR %> placeholders included.
+func (c *ConvertCache) key(file string, index int, text string, line int, storeType, storeName string,
+	creationPolicy esv1beta1.ExternalSecretCreationPolicy, resolver Resolver, opts ConvertOptions) (string, bool) {
+	toolVersion := opts.ToolVersion
+	if toolVersion == "" {
+		toolVersion = buildVersion
+	}
+	if toolVersion == "" {
+		return "", false
+	}
+	key := cacheKey{
+		Format:           cacheFormat,
+		ToolVersion:      toolVersion,
+		File:             file,
+		Index:            index,
+		Line:             line,
+		Text:             text,
+		StoreType:        storeType,
+		StoreName:        storeName,
+		CreationPolicy:   creationPolicy,
+		ExtractPaths:     opts.ExtractPaths,
+		Strict:           opts.Strict,
+		TemplateFromSize: opts.TemplateFromSize,
+		Trace:            opts.Trace,
+		Resolve:          resolver != nil,
+	}
+	if resolver != nil {
+		key.Values = make(map[string]string)
+		for _, placeholder := range findEnvPlaceholders(text) {
+			if value, ok := resolver.Lookup(placeholder.name); ok {
+				key.Values[placeholder.name] = value
+			}
+		}
+	}
+	// encoding a struct of strings, numbers and a string map does not fail
+	body, _ := json.Marshal(key)
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), true
+}
+
+func (c *ConvertCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// get returns the entry of key, a missing or unreadable entry is a miss.
+func (c *ConvertCache) get(key string) (cacheEntry, bool) {
+	body, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var entry cacheEntry
+	if err := decoder.Decode(&entry); err != nil {
+		return cacheEntry{}, false
+	}
+	c.Hits++
+	return entry, true
+}
+
+// put stores the conversion of a document, replacing the entry at once so a
+// concurrent conversion never reads a partial one.
+func (c *ConvertCache) put(key string, resources []interface{}, yamlOutput, warn string) error {
+	entry := cacheEntry{YAML: yamlOutput, Warn: warn}
+	for _, resource := range resources {
+		object, err := resourceMap(resource)
+		if err != nil {
+			return err
+		}
+		entry.Resources = append(entry.Resources, object)
+	}
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(body); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	c.Misses++
+	return nil
+}
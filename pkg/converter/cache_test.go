@@ -0,0 +1,260 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+// setBuildVersion sets the version of the test binary, which has none, for
+// the duration of t.
+func setBuildVersion(t *testing.T, version string) {
+	saved := buildVersion
+	buildVersion = version
+	t.Cleanup(func() { buildVersion = saved })
+}
+
+func TestConvertCache(t *testing.T) {
+	setBuildVersion(t, "test")
+	var body []byte
+	for _, name := range []string{"templated.yaml", "multi_path.yaml"} {
+		content, err := os.ReadFile(filepath.Join("testdata", "golden", name))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		body = append(append(body, content...), "\n---\n"...)
+	}
+	cache, err := NewConvertCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the two secrets of multi_path.yaml depend on ENV
+	tests := []struct {
+		name         string
+		resolver     Resolver
+		opts         ConvertOptions
+		expectHits   int
+		expectMisses int
+	}{
+		{
+			name:         "empty cache",
+			resolver:     MapResolver{"ENV": "dev"},
+			expectMisses: 14,
+		},
+		{
+			name:       "unchanged",
+			resolver:   MapResolver{"ENV": "dev"},
+			expectHits: 14,
+		},
+		{
+			name:       "other output format",
+			resolver:   MapResolver{"ENV": "dev"},
+			opts:       ConvertOptions{Format: OutputFormatNDJSON},
+			expectHits: 14,
+		},
+		{
+			name:         "other values",
+			resolver:     MapResolver{"ENV": "prod"},
+			expectHits:   12,
+			expectMisses: 2,
+		},
+		{
+			name:         "other options",
+			resolver:     MapResolver{"ENV": "dev"},
+			opts:         ConvertOptions{Trace: true, ToolVersion: "v1"},
+			expectMisses: 14,
+		},
+		{
+			name:         "other tool version",
+			resolver:     MapResolver{"ENV": "dev"},
+			opts:         ConvertOptions{Trace: true, ToolVersion: "v2"},
+			expectMisses: 14,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectOutput, expectWarn, err := convertSecretContent("templated.yaml", body, ClusterSecretStoreType, "tenant-b",
+				esv1beta1.CreatePolicyOrphan, tt.resolver, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cache.Hits, cache.Misses = 0, 0
+			tt.opts.Cache = cache
+			output, warn, err := convertSecretContent("templated.yaml", body, ClusterSecretStoreType, "tenant-b",
+				esv1beta1.CreatePolicyOrphan, tt.resolver, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != expectOutput || warn != expectWarn {
+				t.Errorf("output mismatch: got:\n%s%s\nwant:\n%s%s", warn, output, expectWarn, expectOutput)
+			}
+			if cache.Hits != tt.expectHits || cache.Misses != tt.expectMisses {
+				t.Errorf("cache mismatch: got: %d hits %d misses, want: %d hits %d misses",
+					cache.Hits, cache.Misses, tt.expectHits, tt.expectMisses)
+			}
+		})
+	}
+}
+
+func TestConvertCacheCorruptEntry(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  annotations:
+    avp.kubernetes.io/path: "secret/data/app"
+type: Opaque
+stringData:
+  user: <user>
+`)
+	setBuildVersion(t, "test")
+	dir := t.TempDir()
+	cache, err := NewConvertCache(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	opts := ConvertOptions{Cache: cache}
+	expect, _, err := convertSecretContent("", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expect one cache entry, got: %v, %v", entries, err)
+	}
+	if err := os.WriteFile(entries[0], []byte("{"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cache.Hits, cache.Misses = 0, 0
+	output, _, err := convertSecretContent("", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output != expect || cache.Hits != 0 || cache.Misses != 1 {
+		t.Errorf("expect the corrupt entry to be converted again, got %d hits %d misses:\n%s", cache.Hits, cache.Misses, output)
+	}
+}
+
+func TestConvertCacheUnknownVersion(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  annotations:
+    avp.kubernetes.io/path: "secret/data/app"
+type: Opaque
+stringData:
+  user: <user>
+`)
+	tests := []struct {
+		name          string
+		buildVersion  string
+		toolVersion   string
+		expectEntries int
+	}{
+		{
+			name: "unknown build",
+		},
+		{
+			name:          "build revision",
+			buildVersion:  "0123abcd",
+			expectEntries: 1,
+		},
+		{
+			name:          "tool version",
+			toolVersion:   "v1",
+			expectEntries: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setBuildVersion(t, tt.buildVersion)
+			dir := t.TempDir()
+			cache, err := NewConvertCache(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			opts := ConvertOptions{Cache: cache, ToolVersion: tt.toolVersion}
+			if _, _, err := convertSecretContent("", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			entries, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+			if err != nil || len(entries) != tt.expectEntries || cache.Misses != tt.expectEntries {
+				t.Errorf("expect %d cache entries, got: %v, %d misses, %v", tt.expectEntries, entries, cache.Misses, err)
+			}
+		})
+	}
+}
+
+func TestConvertCacheChecks(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  annotations:
+    avp.kubernetes.io/path: "secret/data/<% ENV %>/app"
+type: Opaque
+stringData:
+  user: <user>
+  password: < password >
+`)
+	// the settings convert body, the checks of the changed ones fail it
+	tests := []struct {
+		name          string
+		resolver      Resolver
+		changedValues Resolver
+		changedOpts   ConvertOptions
+		expectErr     string
+	}{
+		{
+			name:        "strict",
+			resolver:    MapResolver{"ENV": "dev"},
+			changedOpts: ConvertOptions{Strict: true},
+			expectErr:   fmt.Sprintf(ErrStrictPlaceholders, 1),
+		},
+		{
+			name:          "values",
+			resolver:      MapResolver{"ENV": "dev"},
+			changedValues: MapResolver{},
+			expectErr:     fmt.Sprintf(ErrCommonNotSetEnv, "ENV"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setBuildVersion(t, "test")
+			cache, err := NewConvertCache(t.TempDir())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			opts := ConvertOptions{Cache: cache}
+			if _, _, err := convertSecretContent("", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, tt.resolver, opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			resolver := tt.resolver
+			if tt.changedValues != nil {
+				resolver = tt.changedValues
+			}
+			tt.changedOpts.Cache = cache
+			cache.Hits, cache.Misses = 0, 0
+			_, _, err = convertSecretContent("", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, resolver, tt.changedOpts)
+			if cache.Hits != 0 {
+				t.Errorf("expect the changed setting to miss the cache, got %d hits", cache.Hits)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("error mismatch: got: %v, want: %s", err, tt.expectErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// diffContext is the number of unchanged lines shown around a change.
+const diffContext = 3
+
+// Drift is an object whose committed output differs from the converted one.
+type Drift struct {
+	// Object names the object, such as ExternalSecret team/app.
+	Object string
+	// Diff has the committed lines prefixed by - and the converted ones by +.
+	Diff string
+}
+
+// CompareOutput compares the committed output with the converted one,
+// ignoring the order of the objects and of spec.data, the formatting and the
+// tool version annotation. Both can be in any of OutputFormats.
+func CompareOutput(committed, converted []byte) ([]Drift, error) {
+	committedObjects, err := parseOutput(committed)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing committed output: %w", err)
+	}
+	convertedObjects, err := parseOutput(converted)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing converted output: %w", err)
+	}
+
+	names := make([]string, 0, len(committedObjects))
+	committedByName := make(map[string]map[string]interface{})
+	for _, object := range committedObjects {
+		name := objectName(object)
+		names = append(names, name)
+		committedByName[name] = object
+	}
+	convertedByName := make(map[string]map[string]interface{})
+	for _, object := range convertedObjects {
+		name := objectName(object)
+		if _, ok := committedByName[name]; !ok {
+			names = append(names, name)
+		}
+		convertedByName[name] = object
+	}
+
+	var drifts []Drift
+	for _, name := range names {
+		before, err := normalizedLines(committedByName[name])
+		if err != nil {
+			return nil, err
+		}
+		after, err := normalizedLines(convertedByName[name])
+		if err != nil {
+			return nil, err
+		}
+		if diff := diffLines(before, after); diff != "" {
+			drifts = append(drifts, Drift{Object: name, Diff: diff})
+		}
+	}
+	return drifts, nil
+}
+
+// parseOutput returns the objects of YAML documents, a JSON array, JSON
+// objects one per line or a v1 List.
+func parseOutput(body []byte) ([]map[string]interface{}, error) {
+	var values []interface{}
+	decoder := yamlv3.NewDecoder(bytes.NewReader(body))
+	for {
+		var value interface{}
+		err := decoder.Decode(&value)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// ndjson is no valid YAML stream
+			if values, err = parseJSONLines(body); err != nil {
+				return nil, err
+			}
+			break
+		}
+		values = append(values, value)
+	}
+
+	var objects []map[string]interface{}
+	for _, value := range values {
+		var items []interface{}
+		switch typed := value.(type) {
+		case nil:
+			continue
+		case []interface{}:
+			items = typed
+		case map[string]interface{}:
+			if typed["kind"] == "List" {
+				items, _ = typed["items"].([]interface{})
+			} else {
+				items = []interface{}{typed}
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %T, expect objects", value)
+		}
+		for _, item := range items {
+			object, err := resourceMap(item)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+func parseJSONLines(body []byte) ([]interface{}, error) {
+	var values []interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(nil, len(body)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(line, &value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, scanner.Err()
+}
+
+func objectName(object map[string]interface{}) string {
+	kind, _ := object["kind"].(string)
+	metadata, _ := object["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	if namespace, _ := metadata["namespace"].(string); namespace != "" {
+		name = namespace + "/" + name
+	}
+	return kind + " " + name
+}
+
+// normalizedLines emits object as sorted YAML without the fields whose
+// order or value does not matter, nil for a missing object.
+func normalizedLines(object map[string]interface{}) ([]string, error) {
+	if object == nil {
+		return nil, nil
+	}
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, TraceAnnotationToolVersion)
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+	if spec, ok := object["spec"].(map[string]interface{}); ok {
+		if data, ok := spec["data"].([]interface{}); ok {
+			sort.SliceStable(data, func(i, j int) bool {
+				return fmt.Sprint(secretKeyOf(data[i])) < fmt.Sprint(secretKeyOf(data[j]))
+			})
+		}
+	}
+	body, err := marshalResource(object)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(body), "\n"), "\n"), nil
+}
+
+func secretKeyOf(value interface{}) interface{} {
+	if data, ok := value.(map[string]interface{}); ok {
+		return data["secretKey"]
+	}
+	return nil
+}
+
+// diffLines returns the changed lines of a and b with diffContext unchanged
+// lines around them, hunks are separated by ..., empty when they are equal.
+func diffLines(a, b []string) string {
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	changed := false
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, "  "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "- "+a[i])
+			changed = true
+			i++
+		default:
+			lines = append(lines, "+ "+b[j])
+			changed = true
+			j++
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	last := -1
+	for idx, line := range lines {
+		if !nearChange(lines, idx) {
+			continue
+		}
+		if last != -1 && idx != last+1 {
+			out.WriteString("...\n")
+		}
+		out.WriteString(line + "\n")
+		last = idx
+	}
+	return out.String()
+}
+
+func nearChange(lines []string, idx int) bool {
+	for near := max(0, idx-diffContext); near <= min(len(lines)-1, idx+diffContext); near++ {
+		if !strings.HasPrefix(lines[near], "  ") {
+			return true
+		}
+	}
+	return false
+}
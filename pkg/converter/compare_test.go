@@ -0,0 +1,147 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompareOutput(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  namespace: team
+  annotations:
+    avp.kubernetes.io/path: "secret/data/app"
+type: Opaque
+stringData:
+  user: <user>
+  url: https://<host>/db
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  namespace: team
+  annotations:
+    avp.kubernetes.io/path: "secret/data/db"
+type: Opaque
+stringData:
+  password: <password>
+`)
+	convert := func(opts ConvertOptions) string {
+		output, _, err := convertSecretContent("secrets.yaml", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return output
+	}
+	converted := convert(ConvertOptions{})
+
+	reordered := strings.Split(converted, "---\n")
+	reordered[1], reordered[2] = reordered[2], reordered[1]
+
+	tests := []struct {
+		name         string
+		committed    string
+		converted    string
+		expectDrifts []string
+		expectDiff   string
+	}{
+		{
+			name:      "same output",
+			committed: converted,
+		},
+		{
+			name:      "objects reordered",
+			committed: strings.Join(reordered, "---\n"),
+		},
+		{
+			name:      "reformatted",
+			committed: strings.Replace(strings.Replace(converted, "property: user", "property: \"user\"", 1), "kind: SecretStore", "kind:   SecretStore", 1),
+		},
+		{
+			name:      "json output",
+			committed: convert(ConvertOptions{Format: OutputFormatJSON}),
+		},
+		{
+			name:      "ndjson output",
+			committed: convert(ConvertOptions{Format: OutputFormatNDJSON}),
+		},
+		{
+			name:      "list output",
+			committed: convert(ConvertOptions{Format: OutputFormatList}),
+		},
+		{
+			name:      "other tool version",
+			committed: convert(ConvertOptions{Trace: true, ToolVersion: "v1"}),
+			converted: convert(ConvertOptions{Trace: true, ToolVersion: "v2"}),
+		},
+		{
+			name:         "edited value",
+			committed:    strings.Replace(converted, "key: db", "key: legacy/db", 1),
+			expectDrifts: []string{"ExternalSecret team/db"},
+			expectDiff:   "-         key: legacy/db\n+         key: db\n",
+		},
+		{
+			name:         "missing object",
+			committed:    strings.Join(reordered[:2], "---\n"),
+			expectDrifts: []string{"ExternalSecret team/app"},
+			expectDiff:   "+ kind: ExternalSecret\n",
+		},
+		{
+			name:         "removed object",
+			committed:    converted + "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: legacy\n",
+			expectDrifts: []string{"ConfigMap legacy"},
+			expectDiff:   "- kind: ConfigMap\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.converted == "" {
+				tt.converted = converted
+			}
+			drifts, err := CompareOutput([]byte(tt.committed), []byte(tt.converted))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var objects []string
+			for _, drift := range drifts {
+				objects = append(objects, drift.Object)
+				if !strings.Contains(drift.Diff, tt.expectDiff) {
+					t.Errorf("diff of %s should contain %q, got:\n%s", drift.Object, tt.expectDiff, drift.Diff)
+				}
+			}
+			if diff := cmp.Diff(tt.expectDrifts, objects); diff != "" {
+				t.Errorf("drifts mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	b := []string{"a", "B", "c", "d", "e", "f", "g", "h", "i", "j", "k"}
+	expect := `  a
+- b
++ B
+  c
+  d
+  e
+...
+  h
+  i
+  j
++ k
+`
+	if got := diffLines(a, b); got != expect {
+		t.Errorf("diffLines() mismatch: got:\n%s\nwant:\n%s", got, expect)
+	}
+	if got := diffLines(a, a); got != "" {
+		t.Errorf("expect no diff of equal lines, got:\n%s", got)
+	}
+}
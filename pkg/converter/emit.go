@@ -0,0 +1,138 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// marshalResource emits obj as a YAML document through a node tree: keys are
+// sorted, multi-line strings are literal block scalars when YAML can hold them
+// and the other strings are only quoted when YAML requires it, so yes, on or
+// 0777 stay strings.
+func marshalResource(obj interface{}) ([]byte, error) {
+	resource, err := resourceMap(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	encoder := yamlv3.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(valueNode(resource)); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// resourceMap turns obj into its JSON object without the fields the API
+// server fills in.
+func resourceMap(obj interface{}) (map[string]interface{}, error) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var resource map[string]interface{}
+	if err := decoder.Decode(&resource); err != nil {
+		return nil, err
+	}
+	pruneResource(resource)
+	return resource, nil
+}
+
+// pruneResource removes the fields the API server fills in.
+func pruneResource(resource map[string]interface{}) {
+	delete(resource, "status")
+	if metadata, ok := resource["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+	if spec, ok := resource["spec"].(map[string]interface{}); ok {
+		if target, ok := spec["target"].(map[string]interface{}); ok {
+			if template, ok := target["template"].(map[string]interface{}); ok {
+				delete(template, "metadata")
+			}
+		}
+	}
+}
+
+// yaml11Bools are the strings YAML 1.1 parsers still read as booleans.
+var yaml11Bools = map[string]bool{
+	"y": true, "Y": true, "yes": true, "Yes": true, "YES": true,
+	"n": true, "N": true, "no": true, "No": true, "NO": true,
+	"on": true, "On": true, "ON": true,
+	"off": true, "Off": true, "OFF": true,
+}
+
+// valueNode returns the node of a value of resourceMap. It is built by hand
+// as Node.Encode reads its own output back, which fails or loses the leading
+// newline of some multi-line strings.
+func valueNode(value interface{}) *yamlv3.Node {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		node := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		for _, key := range keys {
+			node.Content = append(node.Content, stringNode(key), valueNode(value[key]))
+		}
+		return node
+	case []interface{}:
+		node := &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+		for _, item := range value {
+			node.Content = append(node.Content, valueNode(item))
+		}
+		return node
+	case string:
+		return stringNode(value)
+	case json.Number:
+		tag := "!!int"
+		if _, err := value.Int64(); err != nil {
+			tag = "!!float"
+		}
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: tag, Value: value.String()}
+	case bool:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(value)}
+	default:
+		return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+}
+
+func stringNode(value string) *yamlv3.Node {
+	node := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}
+	switch {
+	case strings.Contains(value, "\n") && literalAllowed(value):
+		node.Style = yamlv3.LiteralStyle
+	case strings.Contains(value, "\n"), yaml11Bools[value]:
+		// yaml.v3 emits the other multi-line strings as block scalars too
+		node.Style = yamlv3.DoubleQuotedStyle
+	}
+	return node
+}
+
+// literalAllowed reports whether yaml.v3 reads s back unchanged from a literal
+// block scalar: its first line does not start with a space or a tab, which
+// would be read as indentation, and no line ends with one or has a '\r'.
+func literalAllowed(s string) bool {
+	if strings.HasPrefix(s, " ") || strings.HasPrefix(s, "\t") || strings.HasPrefix(s, "\n") ||
+		strings.Contains(s, "\r") {
+		return false
+	}
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasSuffix(line, " ") || strings.HasSuffix(line, "\t") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestMarshalResource(t *testing.T) {
+	externalSecret := &esv1beta1.ExternalSecret{
+		Spec: esv1beta1.ExternalSecretSpec{
+			SecretStoreRef: esv1beta1.SecretStoreRef{Name: "vault", Kind: SecretStoreType},
+			Data: []esv1beta1.ExternalSecretData{{
+				SecretKey: "user",
+				RemoteRef: esv1beta1.ExternalSecretDataRemoteRef{Key: "app", Property: "user"},
+			}},
+			Target: esv1beta1.ExternalSecretTarget{
+				Template: &esv1beta1.ExternalSecretTemplate{
+					Data: map[string]string{
+						"bool":   "yes",
+						"on":     "on",
+						"mode":   "0777",
+						"plain":  "localhost.local",
+						"user":   "{{ .user }}",
+						"mixed":  `it's "{{ .user }}"`,
+						"quoted": `'"{{ .user }}"'`,
+						"file":   "[client]\nuser = {{ .user }}\n",
+					},
+				},
+			},
+		},
+	}
+	externalSecret.Name = "app"
+
+	body, err := marshalResource(externalSecret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := `metadata:
+  name: app
+spec:
+  data:
+    - remoteRef:
+        key: app
+        property: user
+      secretKey: user
+  secretStoreRef:
+    kind: SecretStore
+    name: vault
+  target:
+    template:
+      data:
+        bool: "yes"
+        file: |
+          [client]
+          user = {{ .user }}
+        mixed: it's "{{ .user }}"
+        mode: "0777"
+        "on": "on"
+        plain: localhost.local
+        quoted: '''"{{ .user }}"'''
+        user: '{{ .user }}'
+`
+	if string(body) != expect {
+		t.Errorf("marshalResource() returned an unexpected document: got:\n%s\nwant:\n%s", body, expect)
+	}
+
+	// the emitted values are read back unchanged
+	var decoded esv1beta1.ExternalSecret
+	if err := yaml.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for key, value := range externalSecret.Spec.Target.Template.Data {
+		if got := decoded.Spec.Target.Template.Data[key]; got != value {
+			t.Errorf("value of %s mismatch: got: %q, want: %q", key, got, value)
+		}
+	}
+}
+
+func TestMarshalResourceMultiLine(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expectStyle string
+	}{
+		{name: "literal", value: "[client]\n\tuser = {{ .user }}\n", expectStyle: "|"},
+		{name: "leading tab", value: "\tx\n{{ .password }}", expectStyle: `"`},
+		{name: "leading space", value: " x\n{{ .password }}", expectStyle: `"`},
+		{name: "leading newline", value: "\n\tx", expectStyle: `"`},
+		{name: "trailing space", value: "x \n{{ .password }}", expectStyle: `"`},
+		{name: "trailing tab", value: "x\n{{ .password }}\t", expectStyle: `"`},
+		{name: "carriage return", value: "x\r\n{{ .password }}", expectStyle: `"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := marshalResource(map[string]interface{}{"value": tt.value})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(string(body), "value: "+tt.expectStyle) {
+				t.Errorf("expect a %s scalar, got:\n%s", tt.expectStyle, body)
+			}
+			var decoded map[string]string
+			if err := yaml.Unmarshal(body, &decoded); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if decoded["value"] != tt.value {
+				t.Errorf("value mismatch: got: %q, want: %q", decoded["value"], tt.value)
+			}
+		})
+	}
+}
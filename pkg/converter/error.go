@@ -9,12 +9,16 @@ const (
 	ErrCommonNotIncludeAngleBrackets           = "not include any angle brackets of secret: %s"
 	ErrCommonNotNeedRefData                    = "not need ref data of secret: %s"
 	ErrCommonNotSetEnv                         = "not set ENV: %s"
+	ErrCommonRequiredEnv                       = "required ENV %s: %s"
 	ErrCommonNotSupportMultipleValue           = "not support set multiple <> with Data Fields: %s"
+	ErrCommonIllegalPathReference              = "illegal path reference <%s>, expect <path:secret/data/path#property>"
 	NotImplSecretType                          = "not impl %s secret type of secret: %s"
 	illegalStoreType                           = "illegal store type: %s"
 	illegalVaultPath                           = "illegal vault path: %s"
 	illegalCreatePolicy                        = "illegal create policy: %s, only support Owner, Orphan"
-	FileContentAngleBracketsParseSyntaxError   = "template syntax error: %s"
+	// Deprecated: no longer returned, the generated templates are checked
+	// with ErrTemplateParse.
+	FileContentAngleBracketsParseSyntaxError = "template syntax error: %s"
 )
 
 const (
@@ -31,3 +35,62 @@ const (
 const (
 	ErrTLSNotAllowDataField = "kubernetes.io/tls type should not allow set Data Fields %s"
 )
+
+const (
+	ErrValuesIllegalSet      = "illegal --set value %q, expect KEY=VALUE"
+	ErrValuesIllegalLine     = "illegal line %d of values file %s, expect KEY=VALUE"
+	ErrValuesNotFlatMap      = "values file %s should be a flat map of variables"
+	ErrValuesNotScalar       = "value of %s in values file %s should be a scalar"
+	ErrValuesUnsupportedFile = "unsupported values file %s, only .env, .yaml, .yml and .json"
+)
+
+const (
+	ErrMatrixEmpty              = "matrix file %s should map environments to their values"
+	ErrMatrixIllegalEnvironment = "illegal environment name %q in matrix file %s"
+)
+
+const (
+	ErrKustomizeEmpty                  = "overlays file %s should map clusters to their store"
+	ErrKustomizeIllegalOverlay         = "illegal overlay name %q"
+	ErrKustomizeIllegalRefreshInterval = "illegal refresh interval %q of overlay %s"
+)
+
+const (
+	ErrOutputUnknownFormat = "unknown output format %q, only %s"
+	ErrOutputEncode        = "error encoding %s output: %w"
+)
+
+const (
+	ErrIncrementalFormat = "output format %s is not supported when converting incrementally, only yaml"
+)
+
+const (
+	ErrGitIllegalRange = "illegal revision range %q"
+)
+
+const (
+	ErrInventoryIllegalPattern = "illegal filter pattern %q"
+)
+
+const (
+	ErrPolicyUnknownGroup   = "unknown policy grouping %q, only %s"
+	ErrPolicyUnresolvedPath = "vault path %s/data/%s of secret %s has unresolved placeholders, resolve them with --resolve, --values or --set"
+)
+
+const (
+	ErrSchemaViolations = "generated objects violate the ExternalSecret schema:%s"
+)
+
+const (
+	ErrTemplateParse = "generated template does not parse: %v"
+)
+
+const (
+	ErrStrictPlaceholders     = "%d suspicious placeholders, fix them or convert without --strict:"
+	ErrPlaceholderPadded      = "%s is not a placeholder, it is padded with spaces"
+	ErrPlaceholderLiteral     = "%s is not a placeholder, it looks like literal text"
+	ErrPlaceholderUnclosed    = "%s is not a placeholder, it is not closed by '>'"
+	ErrPlaceholderEnv         = "%s is not a <%% VAR %%> placeholder"
+	ErrPlaceholderPath        = "%s is not a <path:mount/data/path#property> placeholder"
+	ErrPlaceholderIllegalName = "%s is not a placeholder, a name has only letters, digits, '_', '-' and '.'"
+)
@@ -0,0 +1,246 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The rules of generateEsByOpaqueSecret, also used by the basic-auth and TLS
+// secrets.
+const (
+	ruleDataStaticBase64       = "data without placeholders is base64: decoded by a b64dec literal in the template"
+	ruleDataStatic             = "data without placeholders is no base64: copied to the template, {{ escaped"
+	ruleDataEnv                = "data is an unresolved <% VAR %> placeholder: copied to the template, {{ escaped"
+	ruleDataPlaceholder        = "data with one placeholder: fetched with base64 decoding and rendered into the template"
+	ruleStringDataStatic       = "stringData without placeholders: copied to the template, {{ escaped"
+	ruleStringDataPlaceholders = "stringData with placeholders: every placeholder fetched as is and rendered into the template, {{ escaped"
+	ruleTyped                  = "converted by the rules of %s secrets"
+)
+
+var templateReference = regexp.MustCompile(`\{\{-?\s*\.([^\s{}|]+)`)
+
+// SecretExplanation tells how a secret of the input was converted.
+type SecretExplanation struct {
+	Name      string
+	Namespace string
+	Type      corev1.SecretType
+	Pos       Position
+	// VaultPath is the avp.kubernetes.io/path annotation as written and
+	// ResolvedPath the same once its <% VAR %> placeholders are resolved.
+	VaultPath    string
+	ResolvedPath string
+	// DataFrom is set when the secret mirrors the whole vault path, so its
+	// spec.data is emitted as a single dataFrom.extract.
+	DataFrom bool
+	Keys     []KeyExplanation
+	// Skipped is why the secret has no output, Err why it failed.
+	Skipped string
+	Err     error
+}
+
+// KeyExplanation tells how a key of a secret was converted.
+type KeyExplanation struct {
+	// Field is data or stringData.
+	Field string
+	Key   string
+	// Value is the value as written, before resolving <% VAR %> placeholders.
+	Value string
+	Rule  string
+	// Data are the spec.data entries the template of the key refers to.
+	Data     []esv1beta1.ExternalSecretData
+	Template string
+}
+
+// ExplainSecretFile converts the secrets of inputFile as ConvertSecretFile
+// does and tells how every key was converted. A secret failing to convert
+// is explained with its error instead of stopping.
+func ExplainSecretFile(inputFile, storeType, storeName string, creationPolicy esv1beta1.ExternalSecretCreationPolicy,
+	resolver Resolver, opts ConvertOptions) ([]SecretExplanation, error) {
+	body, err := os.ReadFile(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading inputSecret file: %w", err)
+	}
+	return explainSecretContent(inputFile, body, storeType, storeName, creationPolicy, resolver, opts)
+}
+
+func explainSecretContent(file string, input []byte, storeType, storeName string,
+	creationPolicy esv1beta1.ExternalSecretCreationPolicy, resolver Resolver, opts ConvertOptions) ([]SecretExplanation, error) {
+	inputSecretList, err := parseUnstructuredSecretFile(file, input)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing inputSecret secret: %w", err)
+	}
+
+	var explanations []SecretExplanation
+	for _, inputSecret := range inputSecretList {
+		explanation := SecretExplanation{
+			Name:      inputSecret.Name,
+			Namespace: inputSecret.Namespace,
+			Type:      inputSecret.Type,
+			Pos:       inputSecret.documentPosition(),
+			VaultPath: inputSecret.Annotations["avp.kubernetes.io/path"],
+		}
+		// the conversion resolves the values in place
+		values := map[string]map[string]string{
+			sourceFieldData:       copyValues(inputSecret.Data),
+			sourceFieldStringData: copyValues(inputSecret.StringData),
+		}
+		inputSecret.rules = make(map[string]string)
+
+		if opts.Strict {
+			if err := checkPlaceholders([]internalSecret{inputSecret}); err != nil {
+				explanation.Err = err
+				explanations = append(explanations, explanation)
+				continue
+			}
+		}
+
+		externalSecret, err := convertSecret2ExtSecret(inputSecret, storeType, storeName, creationPolicy, resolver)
+		if err != nil {
+			if errors.Is(err, errSecretSkipped) {
+				explanation.Skipped = err.Error()
+			} else {
+				explanation.Err = err
+			}
+			explanations = append(explanations, explanation)
+			continue
+		}
+		explanation.ResolvedPath = inputSecret.Annotations["avp.kubernetes.io/path"]
+
+		template := externalSecret.Spec.Target.Template
+		for _, field := range []string{sourceFieldData, sourceFieldStringData} {
+			for _, key := range inputSecret.orderedKeys(field, values[field]) {
+				rule, ok := inputSecret.rules[sourceKey(field, key)]
+				if !ok {
+					rule = fmt.Sprintf(ruleTyped, inputSecret.Type)
+				}
+				keyExplanation := KeyExplanation{
+					Field: field,
+					Key:   key,
+					Value: values[field][key],
+					Rule:  rule,
+				}
+				if template != nil {
+					keyExplanation.Template = template.Data[key]
+					keyExplanation.Data = referencedData(externalSecret.Spec.Data, keyExplanation.Template)
+				}
+				explanation.Keys = append(explanation.Keys, keyExplanation)
+			}
+		}
+		explanation.DataFrom = opts.ExtractPaths && compactDataFrom(externalSecret)
+		explanations = append(explanations, explanation)
+	}
+	return explanations, nil
+}
+
+func copyValues(values map[string]string) map[string]string {
+	copied := make(map[string]string, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
+
+// referencedData returns the spec.data entries tmpl refers to, in order of
+// first reference.
+func referencedData(data []esv1beta1.ExternalSecretData, tmpl string) []esv1beta1.ExternalSecretData {
+	var referenced []esv1beta1.ExternalSecretData
+	seen := make(map[string]bool)
+	for _, match := range templateReference.FindAllStringSubmatch(tmpl, -1) {
+		if seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		for _, entry := range data {
+			if entry.SecretKey == match[1] {
+				referenced = append(referenced, entry)
+			}
+		}
+	}
+	return referenced
+}
+
+// HighlightPlaceholders wraps every placeholder of value, <property>,
+// <path:...> or <% VAR %>, in open and close.
+func HighlightPlaceholders(value, open, close string) string {
+	var out strings.Builder
+	last := 0
+	for _, span := range placeholderRanges(value) {
+		out.WriteString(value[last:span[0]])
+		out.WriteString(open + value[span[0]:span[1]] + close)
+		last = span[1]
+	}
+	out.WriteString(value[last:])
+	return out.String()
+}
+
+// FormatExplanation renders e for a terminal, the placeholders of the values
+// are wrapped in open and close.
+func FormatExplanation(e SecretExplanation, open, close string) string {
+	var out strings.Builder
+	name := e.Name
+	if e.Namespace != "" {
+		name = e.Namespace + "/" + name
+	}
+	if e.Pos.IsValid() {
+		out.WriteString(fmt.Sprintf("Secret %s (%s)\n", name, e.Pos))
+	} else {
+		out.WriteString(fmt.Sprintf("Secret %s\n", name))
+	}
+	if e.Type != "" {
+		writeField(&out, "  ", "type", string(e.Type))
+	}
+	writeField(&out, "  ", "vault path", HighlightPlaceholders(e.VaultPath, open, close))
+	switch {
+	case e.Err != nil:
+		writeField(&out, "  ", "error", e.Err.Error())
+		return out.String()
+	case e.Skipped != "":
+		writeField(&out, "  ", "skipped", e.Skipped)
+		return out.String()
+	}
+	if e.ResolvedPath != e.VaultPath {
+		writeField(&out, "  ", "resolved", e.ResolvedPath)
+	}
+	if e.DataFrom {
+		writeField(&out, "  ", "output", "every key mirrors a property of the path, spec.data is emitted as a dataFrom.extract (--extract-paths)")
+	}
+
+	for _, key := range e.Keys {
+		out.WriteString(fmt.Sprintf("  %s.%s:\n", key.Field, key.Key))
+		writeField(&out, "    ", "value", HighlightPlaceholders(key.Value, open, close))
+		writeField(&out, "    ", "rule", key.Rule)
+		for _, data := range key.Data {
+			writeField(&out, "    ", "remoteRef", formatRemoteRef(data))
+		}
+		writeField(&out, "    ", "template", key.Template)
+	}
+	return out.String()
+}
+
+func formatRemoteRef(data esv1beta1.ExternalSecretData) string {
+	ref := data.RemoteRef
+	remote := ref.Key + "#" + ref.Property
+	if ref.Version != "" {
+		remote += "#" + ref.Version
+	}
+	return fmt.Sprintf("%s <- %s, decoding %s", data.SecretKey, remote, ref.DecodingStrategy)
+}
+
+// writeField writes a name: value line, a multi-line value as an indented
+// block below the name.
+func writeField(out *strings.Builder, indent, name, value string) {
+	if !strings.Contains(value, "\n") {
+		out.WriteString(fmt.Sprintf("%s%-11s %s\n", indent, name+":", value))
+		return
+	}
+	out.WriteString(fmt.Sprintf("%s%s: |\n", indent, name))
+	for _, line := range strings.Split(strings.TrimSuffix(value, "\n"), "\n") {
+		out.WriteString(indent + "  " + line + "\n")
+	}
+}
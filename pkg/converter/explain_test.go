@@ -0,0 +1,162 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExplainSecretContent(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  namespace: team
+  annotations:
+    avp.kubernetes.io/path: "secret/data/<% ENV %>/app"
+type: Opaque
+data:
+  token: <token>
+  static: aGVsbG8=
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  namespace: team
+  annotations:
+    avp.kubernetes.io/path: "secret/data/<% ENV %>/app"
+type: Opaque
+stringData:
+  url: https://<host>:<port>/db
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: plain
+  annotations:
+    avp.kubernetes.io/path: "secret/data/plain"
+type: Opaque
+stringData:
+  user: admin
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: broken
+  annotations:
+    avp.kubernetes.io/path: "secret/data/broken"
+type: Opaque
+data:
+  token: <token>
+stringData:
+  user: <user>
+`)
+	resolver := MapResolver{"ENV": "prod"}
+	explanations, err := explainSecretContent("secrets.yaml", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, resolver, ConvertOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(explanations) != 4 {
+		t.Fatalf("expect 4 explanations, got %d", len(explanations))
+	}
+
+	app := explanations[0]
+	app.Keys = append(app.Keys, explanations[1].Keys...)
+	if app.VaultPath != "secret/data/<% ENV %>/app" || app.ResolvedPath != "secret/data/prod/app" {
+		t.Errorf("unexpected vault path %q resolved to %q", app.VaultPath, app.ResolvedPath)
+	}
+	expect := []KeyExplanation{
+		{
+			Field:    sourceFieldData,
+			Key:      "token",
+			Value:    "<token>",
+			Rule:     ruleDataPlaceholder,
+			Template: "{{ .token }}",
+		},
+		{
+			Field:    sourceFieldData,
+			Key:      "static",
+			Value:    "aGVsbG8=",
+			Rule:     ruleDataStaticBase64,
+			Template: `{{ "aGVsbG8=" | b64dec }}`,
+		},
+		{
+			Field:    sourceFieldStringData,
+			Key:      "url",
+			Value:    "https://<host>:<port>/db",
+			Rule:     ruleStringDataPlaceholders,
+			Template: "https://{{ .host }}:{{ .port }}/db",
+		},
+	}
+	var refs [][]string
+	for i := range app.Keys {
+		var keys []string
+		for _, data := range app.Keys[i].Data {
+			keys = append(keys, data.RemoteRef.Key+"#"+data.RemoteRef.Property)
+		}
+		refs = append(refs, keys)
+		app.Keys[i].Data = nil
+	}
+	if diff := cmp.Diff(expect, app.Keys); diff != "" {
+		t.Errorf("keys mismatch (-want +got):\n%s", diff)
+	}
+	expectRefs := [][]string{{"prod/app#token"}, nil, {"prod/app#host", "prod/app#port"}}
+	if diff := cmp.Diff(expectRefs, refs); diff != "" {
+		t.Errorf("remote refs mismatch (-want +got):\n%s", diff)
+	}
+
+	if plain := explanations[2]; plain.Skipped == "" || plain.Keys != nil {
+		t.Errorf("expect the secret without placeholders skipped, got %+v", plain)
+	}
+	if broken := explanations[3]; broken.Err == nil {
+		t.Errorf("expect the error of the secret with both data and stringData, got %+v", broken)
+	}
+}
+
+func TestHighlightPlaceholders(t *testing.T) {
+	tests := []struct {
+		value  string
+		expect string
+	}{
+		{value: "plain", expect: "plain"},
+		{value: "<user>", expect: "[<user>]"},
+		{value: "https://<host>:<port>/db", expect: "https://[<host>]:[<port>]/db"},
+		{value: "secret/data/<% ENV %>/app", expect: "secret/data/[<% ENV %>]/app"},
+	}
+	for _, tt := range tests {
+		if got := HighlightPlaceholders(tt.value, "[", "]"); got != tt.expect {
+			t.Errorf("HighlightPlaceholders(%q) = %q, want %q", tt.value, got, tt.expect)
+		}
+	}
+}
+
+func TestFormatExplanation(t *testing.T) {
+	got := FormatExplanation(SecretExplanation{
+		Name:         "app",
+		Namespace:    "team",
+		VaultPath:    "secret/data/app",
+		ResolvedPath: "secret/data/app",
+		Keys: []KeyExplanation{{
+			Field:    sourceFieldStringData,
+			Key:      "conf",
+			Value:    "user = <user>\n",
+			Rule:     ruleStringDataPlaceholders,
+			Template: "user = {{ .user }}\n",
+		}},
+	}, "[", "]")
+	expect := `Secret team/app
+  vault path: secret/data/app
+  stringData.conf:
+    value: |
+      user = [<user>]
+    rule:       ` + ruleStringDataPlaceholders + `
+    template: |
+      user = {{ .user }}
+`
+	if got != expect {
+		t.Errorf("FormatExplanation() mismatch:\n%s", cmp.Diff(strings.Split(expect, "\n"), strings.Split(got, "\n")))
+	}
+}
@@ -0,0 +1,152 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// keyCase is a case transform ESO can apply to the extracted keys.
+type keyCase struct {
+	template string
+	apply    func(string) string
+}
+
+var keyCases = []keyCase{
+	{apply: func(s string) string { return s }},
+	{template: "{{ .value | lower }}", apply: strings.ToLower},
+	{template: "{{ .value | upper }}", apply: strings.ToUpper},
+}
+
+// compactDataFrom replaces the spec.data of an ExternalSecret mirroring a
+// whole vault path, every template key echoing a property of the same path, by
+// a single dataFrom.extract. The key names may differ from the properties by a
+// case or a prefix, which is done by rewrite rules. It reports whether the
+// ExternalSecret was compacted.
+func compactDataFrom(externalSecret *esv1beta1.ExternalSecret) bool {
+	spec := &externalSecret.Spec
+	template := spec.Target.Template
+	if template == nil || template.Type != corev1.SecretTypeOpaque || len(spec.Data) == 0 || len(spec.DataFrom) != 0 ||
+		len(template.TemplateFrom) != 0 || len(template.Data) != len(spec.Data) {
+		return false
+	}
+
+	remoteRef := spec.Data[0].RemoteRef
+	// properties are keyed by the template expression echoing them
+	properties := make(map[string]string)
+	for _, data := range spec.Data {
+		ref := data.RemoteRef
+		if ref.Key != remoteRef.Key || ref.Version != remoteRef.Version ||
+			ref.DecodingStrategy != remoteRef.DecodingStrategy ||
+			ref.ConversionStrategy != remoteRef.ConversionStrategy ||
+			ref.MetadataPolicy != remoteRef.MetadataPolicy ||
+			data.SecretKey != ref.Property || data.SourceRef != nil {
+			return false
+		}
+		properties[templateExpression(data.SecretKey)] = ref.Property
+	}
+
+	keys := make(map[string]string)
+	for key, value := range template.Data {
+		property, ok := properties[value]
+		if !ok {
+			return false
+		}
+		if _, ok := keys[property]; ok {
+			return false
+		}
+		keys[property] = key
+	}
+
+	rewrite, ok := keyRewrite(keys)
+	if !ok {
+		return false
+	}
+
+	remoteRef.Property = ""
+	spec.Data = nil
+	spec.DataFrom = []esv1beta1.ExternalSecretDataFromRemoteRef{{
+		Extract: &remoteRef,
+		Rewrite: rewrite,
+	}}
+	template.Data = nil
+	return true
+}
+
+// keyRewrite returns the rewrite rules turning every property into its key,
+// keys maps the properties to their keys.
+func keyRewrite(keys map[string]string) ([]esv1beta1.ExternalSecretRewrite, bool) {
+	for _, c := range keyCases {
+		var rewrite []esv1beta1.ExternalSecretRewrite
+		if c.template != "" {
+			rewrite = append(rewrite, esv1beta1.ExternalSecretRewrite{
+				Transform: &esv1beta1.ExternalSecretRewriteTransform{Template: c.template},
+			})
+		}
+
+		transformed := make(map[string]string)
+		for property, key := range keys {
+			transformed[c.apply(property)] = key
+		}
+		if len(transformed) != len(keys) {
+			continue
+		}
+
+		if prefixRule, ok := prefixRewrite(transformed); ok {
+			if prefixRule != nil {
+				rewrite = append(rewrite, *prefixRule)
+			}
+			return rewrite, true
+		}
+	}
+	return nil, false
+}
+
+// prefixRewrite returns the regexp rule adding or removing the same prefix to
+// every property, nil when the properties already are the keys.
+func prefixRewrite(keys map[string]string) (*esv1beta1.ExternalSecretRewrite, bool) {
+	identity := true
+	for property, key := range keys {
+		if property != key {
+			identity = false
+			break
+		}
+	}
+	if identity {
+		return nil, true
+	}
+
+	if prefix, ok := commonPrefix(keys, func(property, key string) (string, bool) {
+		return strings.CutSuffix(key, property)
+	}); ok {
+		return &esv1beta1.ExternalSecretRewrite{
+			Regexp: &esv1beta1.ExternalSecretRewriteRegexp{Source: "^", Target: strings.ReplaceAll(prefix, "$", "$$")},
+		}, true
+	}
+	if prefix, ok := commonPrefix(keys, func(property, key string) (string, bool) {
+		return strings.CutSuffix(property, key)
+	}); ok {
+		return &esv1beta1.ExternalSecretRewrite{
+			Regexp: &esv1beta1.ExternalSecretRewriteRegexp{Source: fmt.Sprintf("^%s", regexp.QuoteMeta(prefix)), Target: ""},
+		}, true
+	}
+	return nil, false
+}
+
+// commonPrefix returns the non-empty prefix cut returns for every property and
+// key when it is the same for all of them.
+func commonPrefix(keys map[string]string, cut func(property, key string) (string, bool)) (string, bool) {
+	var prefix string
+	first := true
+	for property, key := range keys {
+		p, ok := cut(property, key)
+		if !ok || p == "" || (!first && p != prefix) {
+			return "", false
+		}
+		prefix, first = p, false
+	}
+	return prefix, true
+}
@@ -0,0 +1,175 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompactDataFrom(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		compact bool
+		decode  esv1beta1.ExternalSecretDecodingStrategy
+		rewrite []esv1beta1.ExternalSecretRewrite
+	}{
+		{
+			name: "keys are the properties",
+			body: `stringData:
+  user: <user>
+  password: <password>`,
+			compact: true,
+			decode:  esv1beta1.ExternalSecretDecodeNone,
+		},
+		{
+			name: "base64 data keeps its decoding strategy",
+			body: `data:
+  user: <user>
+  password: <password>`,
+			compact: true,
+			decode:  esv1beta1.ExternalSecretDecodeBase64,
+		},
+		{
+			name: "keys are the lower case properties",
+			body: `stringData:
+  user: <USER>
+  password: <PASSWORD>`,
+			compact: true,
+			decode:  esv1beta1.ExternalSecretDecodeNone,
+			rewrite: []esv1beta1.ExternalSecretRewrite{
+				{Transform: &esv1beta1.ExternalSecretRewriteTransform{Template: "{{ .value | lower }}"}},
+			},
+		},
+		{
+			name: "keys add a prefix to the upper case properties",
+			body: `stringData:
+  DB_USER: <user>
+  DB_PASSWORD: <password>`,
+			compact: true,
+			decode:  esv1beta1.ExternalSecretDecodeNone,
+			rewrite: []esv1beta1.ExternalSecretRewrite{
+				{Transform: &esv1beta1.ExternalSecretRewriteTransform{Template: "{{ .value | upper }}"}},
+				{Regexp: &esv1beta1.ExternalSecretRewriteRegexp{Source: "^", Target: "DB_"}},
+			},
+		},
+		{
+			name: "keys remove a prefix of the properties",
+			body: `stringData:
+  user: <mysql.user>
+  password: <mysql.password>`,
+			compact: true,
+			decode:  esv1beta1.ExternalSecretDecodeNone,
+			rewrite: []esv1beta1.ExternalSecretRewrite{
+				{Regexp: &esv1beta1.ExternalSecretRewriteRegexp{Source: `^mysql\.`, Target: ""}},
+			},
+		},
+		{
+			name: "keys with different prefixes",
+			body: `stringData:
+  db_user: <user>
+  app_password: <password>`,
+		},
+		{
+			name: "value with more than the property",
+			body: `stringData:
+  user: <user>
+  url: https://<host>/`,
+		},
+		{
+			name: "property used by two keys",
+			body: `stringData:
+  user: <user>
+  login: <user>`,
+		},
+		{
+			name: "static value",
+			body: `stringData:
+  user: <user>
+  port: "3306"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputSecretList, err := parseUnstructuredSecret([]byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: mirror
+  annotations:
+    avp.kubernetes.io/path: "secret/data/db"
+type: Opaque
+` + tt.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			externalSecret, err := convertSecret2ExtSecret(inputSecretList[0], SecretStoreType, "test", esv1beta1.CreatePolicyOwner, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			data := externalSecret.Spec.Data
+
+			if compactDataFrom(externalSecret) != tt.compact {
+				t.Fatalf("compactDataFrom() mismatch: want: %v", tt.compact)
+			}
+			if !tt.compact {
+				if diff := cmp.Diff(data, externalSecret.Spec.Data); diff != "" {
+					t.Errorf("data should be kept (-want +got):\n%s", diff)
+				}
+				return
+			}
+
+			expect := []esv1beta1.ExternalSecretDataFromRemoteRef{{
+				Extract: &esv1beta1.ExternalSecretDataRemoteRef{
+					Key:                "db",
+					ConversionStrategy: esv1beta1.ExternalSecretConversionDefault,
+					DecodingStrategy:   tt.decode,
+					MetadataPolicy:     esv1beta1.ExternalSecretMetadataPolicyNone,
+				},
+				Rewrite: tt.rewrite,
+			}}
+			if diff := cmp.Diff(expect, externalSecret.Spec.DataFrom); diff != "" {
+				t.Errorf("dataFrom mismatch (-want +got):\n%s", diff)
+			}
+			if externalSecret.Spec.Data != nil || externalSecret.Spec.Target.Template.Data != nil {
+				t.Errorf("data and template data should be removed")
+			}
+		})
+	}
+}
+
+func TestConvertExtractPaths(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  annotations:
+    avp.kubernetes.io/path: "secret/data/app"
+type: Opaque
+stringData:
+  user: <user>
+  password: <password>
+`)
+	tests := []struct {
+		name   string
+		opts   ConvertOptions
+		expect string
+		absent string
+	}{
+		{name: "spec.data by default", expect: "property: password", absent: "dataFrom:"},
+		{name: "extract paths", opts: ConvertOptions{ExtractPaths: true}, expect: "dataFrom:", absent: "property: password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := convertSecretContent("", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(output, tt.expect) || strings.Contains(output, tt.absent) {
+				t.Errorf("expect %q and no %q in output:\n%s", tt.expect, tt.absent, output)
+			}
+		})
+	}
+}
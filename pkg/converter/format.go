@@ -0,0 +1,100 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	OutputFormatYAML   = "yaml"
+	OutputFormatJSON   = "json"
+	OutputFormatNDJSON = "ndjson"
+	OutputFormatList   = "list"
+)
+
+// OutputFormats are the formats of the converted resources.
+var OutputFormats = []string{OutputFormatYAML, OutputFormatJSON, OutputFormatNDJSON, OutputFormatList}
+
+// VerifyOutputFormat returns an error for an unknown format, the empty format
+// is YAML.
+func VerifyOutputFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, known := range OutputFormats {
+		if format == known {
+			return nil
+		}
+	}
+	return fmt.Errorf(ErrOutputUnknownFormat, format, strings.Join(OutputFormats, ", "))
+}
+
+// formatOutput emits the resources in format: `---` separated YAML documents,
+// a pretty JSON array, one JSON object per line or a single v1 List.
+func formatOutput(resources []interface{}, format string) (string, error) {
+	if err := VerifyOutputFormat(format); err != nil {
+		return "", err
+	}
+
+	if format == "" || format == OutputFormatYAML {
+		var output strings.Builder
+		for _, resource := range resources {
+			body, err := marshalResource(resource)
+			if err != nil {
+				return "", err
+			}
+			output.WriteString("---\n")
+			output.Write(body)
+		}
+		return output.String(), nil
+	}
+
+	items := make([]interface{}, 0, len(resources))
+	for _, resource := range resources {
+		item, err := resourceMap(resource)
+		if err != nil {
+			return "", err
+		}
+		items = append(items, item)
+	}
+
+	switch format {
+	case OutputFormatNDJSON:
+		var output strings.Builder
+		for _, item := range items {
+			line, err := marshalJSON(item, false)
+			if err != nil {
+				return "", err
+			}
+			output.Write(line)
+		}
+		return output.String(), nil
+	case OutputFormatList:
+		body, err := marshalJSON(map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "List",
+			"metadata":   map[string]interface{}{},
+			"items":      items,
+		}, true)
+		return string(body), err
+	default:
+		body, err := marshalJSON(items, true)
+		return string(body), err
+	}
+}
+
+// marshalJSON keeps <, > and & as is, they are common in templates.
+func marshalJSON(value interface{}, pretty bool) ([]byte, error) {
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if pretty {
+		encoder.SetIndent("", "  ")
+	}
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
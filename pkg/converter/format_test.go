@@ -0,0 +1,122 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+func TestFormatOutput(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  annotations:
+    avp.kubernetes.io/path: "secret/data/app"
+type: Opaque
+stringData:
+  url: https://<host>/?a=1&b=2
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  annotations:
+    avp.kubernetes.io/path: "secret/data/db"
+type: Opaque
+stringData:
+  user: <user>
+`)
+
+	tests := []struct {
+		format string
+		check  func(t *testing.T, output string)
+	}{
+		{
+			format: OutputFormatYAML,
+			check: func(t *testing.T, output string) {
+				if strings.Count(output, "---\n") != 2 || !strings.HasPrefix(output, "---\n") {
+					t.Errorf("expect two YAML documents, got:\n%s", output)
+				}
+			},
+		},
+		{
+			format: OutputFormatJSON,
+			check: func(t *testing.T, output string) {
+				var items []map[string]interface{}
+				if err := json.Unmarshal([]byte(output), &items); err != nil {
+					t.Fatalf("expect a JSON array: %v", err)
+				}
+				if len(items) != 2 || items[0]["kind"] != "ExternalSecret" {
+					t.Errorf("expect two external secrets, got:\n%s", output)
+				}
+				if !strings.Contains(output, "\n  {\n") {
+					t.Errorf("expect pretty JSON, got:\n%s", output)
+				}
+			},
+		},
+		{
+			format: OutputFormatNDJSON,
+			check: func(t *testing.T, output string) {
+				lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+				if len(lines) != 2 {
+					t.Fatalf("expect one line per resource, got:\n%s", output)
+				}
+				for _, line := range lines {
+					var item map[string]interface{}
+					if err := json.Unmarshal([]byte(line), &item); err != nil {
+						t.Errorf("expect a JSON object per line: %v", err)
+					}
+				}
+			},
+		},
+		{
+			format: OutputFormatList,
+			check: func(t *testing.T, output string) {
+				var list struct {
+					APIVersion string                   `json:"apiVersion"`
+					Kind       string                   `json:"kind"`
+					Items      []map[string]interface{} `json:"items"`
+				}
+				if err := json.Unmarshal([]byte(output), &list); err != nil {
+					t.Fatalf("expect a JSON object: %v", err)
+				}
+				if list.APIVersion != "v1" || list.Kind != "List" || len(list.Items) != 2 {
+					t.Errorf("expect a v1 List of two items, got:\n%s", output)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			output, _, err := convertSecretContent("", body, SecretStoreType, "test", esv1beta1.CreatePolicyOwner, nil,
+				ConvertOptions{Format: tt.format})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Contains(output, "status") || strings.Contains(output, "creationTimestamp") {
+				t.Errorf("expect the server filled fields to be pruned, got:\n%s", output)
+			}
+			if tt.format != OutputFormatYAML && !strings.Contains(output, "?a=1&b=2") {
+				t.Errorf("expect & to stay unescaped, got:\n%s", output)
+			}
+			tt.check(t, output)
+		})
+	}
+}
+
+func TestVerifyOutputFormat(t *testing.T) {
+	for _, format := range append([]string{""}, OutputFormats...) {
+		if err := VerifyOutputFormat(format); err != nil {
+			t.Errorf("format %q: unexpected error: %v", format, err)
+		}
+	}
+	expect := fmt.Errorf(ErrOutputUnknownFormat, "xml", "yaml, json, ndjson, list").Error()
+	if err := VerifyOutputFormat("xml"); err == nil || err.Error() != expect {
+		t.Errorf("error mismatch: got: %v, want: %s", err, expect)
+	}
+}
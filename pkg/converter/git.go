@@ -0,0 +1,49 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ChangedFiles returns the YAML files under dir that were added, copied,
+// modified or renamed in the revision range of the local git repository, such
+// as main...HEAD, or in the index when staged. The paths are joined to dir
+// and sorted, the deleted files are left out.
+func ChangedFiles(dir, revRange string, staged bool) ([]string, error) {
+	if strings.HasPrefix(revRange, "-") {
+		return nil, fmt.Errorf(ErrGitIllegalRange, revRange)
+	}
+
+	args := []string{"-C", dir, "diff", "--name-only", "--relative", "--diff-filter=ACMR", "-z"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	if revRange != "" {
+		args = append(args, revRange)
+	}
+	args = append(args, "--")
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("error running git diff in %s: %w: %s", dir, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var files []string
+	for _, name := range strings.Split(stdout.String(), "\x00") {
+		if name == "" {
+			continue
+		}
+		if ext := strings.ToLower(filepath.Ext(name)); ext == ".yaml" || ext == ".yml" {
+			files = append(files, filepath.Join(dir, filepath.FromSlash(name)))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,113 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	write("apps/a.yaml", "a: 1\n")
+	write("apps/b.yaml", "b: 1\n")
+	write("apps/c.yaml", "c: 1\n")
+	write("infra/d.yaml", "d: 1\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "base")
+
+	write("apps/a.yaml", "a: 2\n")
+	write("apps/e.yml", "e: 1\n")
+	write("apps/readme.md", "apps\n")
+	write("infra/d.yaml", "d: 2\n")
+	run("rm", "-q", "apps/b.yaml")
+	run("add", ".")
+	run("commit", "-q", "-m", "change")
+
+	write("apps/c.yaml", "c: 2\n")
+	run("add", "apps/c.yaml")
+
+	tests := []struct {
+		name      string
+		dir       string
+		revRange  string
+		staged    bool
+		expect    []string
+		expectErr error
+	}{
+		{
+			name:     "revision range",
+			dir:      repo,
+			revRange: "HEAD~1..HEAD",
+			expect:   []string{"apps/a.yaml", "apps/e.yml", "infra/d.yaml"},
+		},
+		{
+			name:     "sub directory",
+			dir:      filepath.Join(repo, "apps"),
+			revRange: "HEAD~1..HEAD",
+			expect:   []string{"apps/a.yaml", "apps/e.yml"},
+		},
+		{
+			name:   "staged",
+			dir:    repo,
+			staged: true,
+			expect: []string{"apps/c.yaml"},
+		},
+		{
+			name:      "option as range",
+			dir:       repo,
+			revRange:  "--output=x",
+			expectErr: fmt.Errorf(ErrGitIllegalRange, "--output=x"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := ChangedFiles(tt.dir, tt.revRange, tt.staged)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("error mismatch: got: %v, want: %v", err, tt.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var expect []string
+			for _, name := range tt.expect {
+				expect = append(expect, filepath.Join(repo, filepath.FromSlash(name)))
+			}
+			if diff := cmp.Diff(expect, files); diff != "" {
+				t.Errorf("files mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := ChangedFiles(repo, "unknown..HEAD", false); err == nil {
+		t.Errorf("expect an error for an unknown revision")
+	}
+}
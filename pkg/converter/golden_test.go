@@ -0,0 +1,62 @@
+package converter
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of testdata/golden")
+
+func TestGoldenOutput(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "golden", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no golden input found")
+	}
+
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			body, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			convert := func() string {
+				output, warn, err := convertSecretContent(filepath.Base(input), body, ClusterSecretStoreType, "tenant-b",
+					esv1beta1.CreatePolicyOrphan, MapResolver{"ENV": "dev"}, ConvertOptions{})
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return warn + output
+			}
+
+			output := convert()
+			// the map iteration order differs between runs
+			for i := 0; i < 20; i++ {
+				if again := convert(); again != output {
+					t.Fatalf("output differs between runs:\n%s\n---\n%s", output, again)
+				}
+			}
+
+			golden := strings.TrimSuffix(input, ".yaml") + ".golden"
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(output), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expect, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file, run go test -run TestGoldenOutput -update: %v", err)
+			}
+			if output != string(expect) {
+				t.Errorf("output mismatch with %s, got:\n%s", golden, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	esoTemplateExpression = regexp.MustCompile(`\{\{.*?\}\}`)
+	secretStoreRefLine    = regexp.MustCompile(`^(\s*)secretStoreRef:\s*$`)
+)
+
+// HelmOptions controls how converted ExternalSecrets are made safe to ship in
+// a Helm chart.
+type HelmOptions struct {
+	// ValuesFromEnv turns the unresolved <% VAR %> into {{ .Values.var }}.
+	ValuesFromEnv bool
+	// StoreFromValues reads the SecretStoreRef name and kind from
+	// {{ .Values.secretStore }}, as the chart written by WriteHelmChart does.
+	StoreFromValues bool
+}
+
+// HelmTemplate escapes the ESO template expressions of output so Helm renders
+// them verbatim, {{ .password }} becomes {{ "{{ .password }}" }}. It returns
+// the Helm values referenced by the template with their defaults.
+func HelmTemplate(output string, opts HelmOptions) (string, map[string]string) {
+	values := make(map[string]string)
+	lines := strings.Split(output, "\n")
+	for idx, line := range lines {
+		lines[idx] = helmLine(line, opts, values)
+	}
+	if opts.StoreFromValues {
+		lines = helmSecretStoreRef(lines)
+	}
+	return strings.Join(lines, "\n"), values
+}
+
+func helmLine(line string, opts HelmOptions, values map[string]string) string {
+	if !opts.ValuesFromEnv {
+		return escapeESOTemplate(line)
+	}
+
+	var result strings.Builder
+	last := 0
+	for _, placeholder := range findEnvPlaceholders(line) {
+		result.WriteString(escapeESOTemplate(line[last:placeholder.offset]))
+		result.WriteString(placeholder.helmExpression(values))
+		last = placeholder.end
+	}
+	result.WriteString(escapeESOTemplate(line[last:]))
+	return result.String()
+}
+
+// escapeESOTemplate wraps the template expressions of text in Helm string
+// literals, text broken by a <% VAR %> in the middle of an expression is
+// wrapped as a whole.
+func escapeESOTemplate(text string) string {
+	if !strings.Contains(text, "{{") && !strings.Contains(text, "}}") {
+		return text
+	}
+	rest := esoTemplateExpression.ReplaceAllString(text, "")
+	if strings.Contains(rest, "{{") || strings.Contains(rest, "}}") {
+		return helmLiteral(text)
+	}
+	return esoTemplateExpression.ReplaceAllStringFunc(text, helmLiteral)
+}
+
+func helmLiteral(text string) string {
+	return fmt.Sprintf("{{ %s }}", strconv.Quote(text))
+}
+
+// helmExpression is the Helm equivalent of the placeholder, recording the
+// value with its default.
+func (p envPlaceholder) helmExpression(values map[string]string) string {
+	name := helmValueName(p.name)
+	if p.modifier == envModifierDefault {
+		values[name] = p.argument
+	} else if _, ok := values[name]; !ok {
+		values[name] = ""
+	}
+	switch p.modifier {
+	case envModifierDefault:
+		return fmt.Sprintf("{{ .Values.%s | default %s }}", name, strconv.Quote(p.argument))
+	case envModifierRequired:
+		return fmt.Sprintf("{{ required %s .Values.%s }}", strconv.Quote(p.argument), name)
+	}
+	return fmt.Sprintf("{{ .Values.%s }}", name)
+}
+
+// helmValueName turns an ENV_VAR name into the lowerCamel envVar Helm value.
+func helmValueName(name string) string {
+	var result strings.Builder
+	for idx, part := range strings.Split(strings.ToLower(name), "_") {
+		if part == "" {
+			continue
+		}
+		if idx > 0 && result.Len() > 0 {
+			runes := []rune(part)
+			runes[0] = unicode.ToUpper(runes[0])
+			part = string(runes)
+		}
+		result.WriteString(part)
+	}
+	if result.Len() == 0 {
+		return name
+	}
+	return result.String()
+}
+
+// helmSecretStoreRef replaces the name and kind of every secretStoreRef block
+// with the store of the chart values.
+func helmSecretStoreRef(lines []string) []string {
+	for idx := 0; idx < len(lines); idx++ {
+		match := secretStoreRefLine.FindStringSubmatch(lines[idx])
+		if match == nil {
+			continue
+		}
+		indent := len(match[1])
+		for next := idx + 1; next < len(lines); next++ {
+			line := lines[next]
+			trimmed := strings.TrimLeft(line, " ")
+			if len(line)-len(trimmed) <= indent {
+				break
+			}
+			prefix := line[:len(line)-len(trimmed)]
+			switch {
+			case strings.HasPrefix(trimmed, "kind:"):
+				lines[next] = prefix + "kind: {{ .Values.secretStore.kind }}"
+			case strings.HasPrefix(trimmed, "name:"):
+				lines[next] = prefix + "name: {{ .Values.secretStore.name }}"
+			}
+		}
+	}
+	return lines
+}
+
+// WriteHelmChart writes a minimal chart to dir, templates maps the file names
+// under templates/ to their content as returned by HelmTemplate.
+func WriteHelmChart(dir string, templates map[string]string, storeType, storeName string, values map[string]string) error {
+	chartName := filepath.Base(filepath.Clean(dir))
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0o755); err != nil {
+		return err
+	}
+
+	chart := fmt.Sprintf(`apiVersion: v2
+name: %s
+description: ExternalSecrets converted from argocd-vault-plugin secrets by secret2es
+type: application
+version: 0.1.0
+`, chartName)
+	if err := os.WriteFile(filepath.Join(dir, "Chart.yaml"), []byte(chart), 0o644); err != nil {
+		return err
+	}
+
+	chartValues := map[string]interface{}{
+		"secretStore": map[string]string{
+			"name": storeName,
+			"kind": storeType,
+		},
+	}
+	for name, value := range values {
+		chartValues[name] = value
+	}
+	valuesBody, err := yaml.Marshal(chartValues)
+	if err != nil {
+		return fmt.Errorf("error encoding helm values: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "values.yaml"), valuesBody, 0o644); err != nil {
+		return err
+	}
+
+	for name, content := range templates {
+		if err := os.WriteFile(filepath.Join(dir, "templates", name), []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
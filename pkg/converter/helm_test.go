@@ -0,0 +1,115 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHelmTemplate(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		opts         HelmOptions
+		expect       string
+		expectValues map[string]string
+	}{
+		{
+			name:         "escape eso template",
+			input:        `        user: "{{ .USER }}-{{ .TENANT | b64dec }}"`,
+			expect:       `        user: "{{ "{{ .USER }}" }}-{{ "{{ .TENANT | b64dec }}" }}"`,
+			expectValues: map[string]string{},
+		},
+		{
+			name:         "escape quoted expression",
+			input:        `        conf: "{{ "a" | b64dec }}"`,
+			expect:       `        conf: "{{ "{{ \"a\" | b64dec }}" }}"`,
+			expectValues: map[string]string{},
+		},
+		{
+			name:         "escape expression broken by a placeholder",
+			input:        `        key: {{ .<% ENV1 %>_VAULT1 }}`,
+			expect:       `        key: {{ "{{ .<% ENV1 %>_VAULT1 }}" }}`,
+			expectValues: map[string]string{},
+		},
+		{
+			name:         "keep placeholder without values",
+			input:        `      key: <% ENV %>/app`,
+			expect:       `      key: <% ENV %>/app`,
+			expectValues: map[string]string{},
+		},
+		{
+			name:   "placeholders as values",
+			input:  `      key: <% VAULT_ENV %>/<% REGION | default "eu" %>/<% TENANT | required "tenant is required" %>`,
+			opts:   HelmOptions{ValuesFromEnv: true},
+			expect: `      key: {{ .Values.vaultEnv }}/{{ .Values.region | default "eu" }}/{{ required "tenant is required" .Values.tenant }}`,
+			expectValues: map[string]string{
+				"vaultEnv": "",
+				"region":   "eu",
+				"tenant":   "",
+			},
+		},
+		{
+			name: "secret store from values",
+			input: `  secretStoreRef:
+    kind: SecretStore
+    name: vault
+  target:
+    name: app`,
+			opts: HelmOptions{StoreFromValues: true},
+			expect: `  secretStoreRef:
+    kind: {{ .Values.secretStore.kind }}
+    name: {{ .Values.secretStore.name }}
+  target:
+    name: app`,
+			expectValues: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, values := HelmTemplate(tt.input, tt.opts)
+			if out != tt.expect {
+				t.Errorf("HelmTemplate() returned an unexpected string: got:\n%s\nwant:\n%s", out, tt.expect)
+			}
+			if diff := cmp.Diff(tt.expectValues, values); diff != "" {
+				t.Errorf("values mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteHelmChart(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "app-secrets")
+	templates := map[string]string{"secret.yaml": "kind: ExternalSecret\n"}
+	err := WriteHelmChart(dir, templates, SecretStoreType, "vault", map[string]string{"region": "eu"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expect := map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: app-secrets
+description: ExternalSecrets converted from argocd-vault-plugin secrets by secret2es
+type: application
+version: 0.1.0
+`,
+		"values.yaml": `region: eu
+secretStore:
+  kind: SecretStore
+  name: vault
+`,
+		filepath.Join("templates", "secret.yaml"): "kind: ExternalSecret\n",
+	}
+	for name, content := range expect {
+		body, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(body) != content {
+			t.Errorf("%s mismatch: got:\n%s\nwant:\n%s", name, body, content)
+		}
+	}
+}
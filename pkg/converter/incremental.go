@@ -0,0 +1,111 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+// documentKey identifies a converted document: its text and where it is in
+// the file, as the positions of warnings and the trace annotations depend on it.
+type documentKey struct {
+	index int
+	line  int
+	sum   [sha256.Size]byte
+}
+
+type convertedDocument struct {
+	output string
+	warn   string
+}
+
+// IncrementalConverter converts files again and again, only converting the
+// documents changed since the last conversion of the file. The output is YAML.
+type IncrementalConverter struct {
+	storeType      string
+	storeName      string
+	creationPolicy esv1beta1.ExternalSecretCreationPolicy
+	resolver       Resolver
+	opts           ConvertOptions
+
+	files map[string]map[documentKey]convertedDocument
+}
+
+// NewIncrementalConverter returns an IncrementalConverter with the settings
+// of ConvertSecretFile, opts.Format must be YAML.
+func NewIncrementalConverter(storeType, storeName string, creationPolicy esv1beta1.ExternalSecretCreationPolicy,
+	resolver Resolver, opts ConvertOptions) (*IncrementalConverter, error) {
+	if opts.Format != "" && opts.Format != OutputFormatYAML {
+		return nil, fmt.Errorf(ErrIncrementalFormat, opts.Format)
+	}
+	return &IncrementalConverter{
+		storeType:      storeType,
+		storeName:      storeName,
+		creationPolicy: creationPolicy,
+		resolver:       resolver,
+		opts:           opts,
+		files:          make(map[string]map[documentKey]convertedDocument),
+	}, nil
+}
+
+// ConvertFile converts inputFile as ConvertSecretFile does and returns how
+// many of its secrets were converted instead of taken from the last conversion.
+func (c *IncrementalConverter) ConvertFile(inputFile string) (string, string, int, error) {
+	body, err := os.ReadFile(inputFile)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("error reading inputSecret file: %w", err)
+	}
+	lines := strings.Split(string(body), "\n")
+	docs := splitYAMLDocuments(body)
+
+	// only the documents changed since the last conversion are converted
+	previous := c.files[inputFile]
+	keys := make([]documentKey, len(docs))
+	var changed []int
+	for index, doc := range docs {
+		if !isSecretDocument(doc) {
+			continue
+		}
+		keys[index] = documentKey{
+			index: index,
+			line:  doc.lines[0],
+			sum:   sha256.Sum256([]byte(documentText(lines, doc))),
+		}
+		if _, ok := previous[keys[index]]; !ok {
+			changed = append(changed, index)
+		}
+	}
+	converted, err := convertDocuments(inputFile, lines, docs, changed, c.storeType, c.storeName, c.creationPolicy, c.resolver, c.opts)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("error converting secret: %w", err)
+	}
+
+	current := make(map[documentKey]convertedDocument, len(changed))
+	output, warn := "", ""
+	for index, doc := range docs {
+		if !isSecretDocument(doc) {
+			continue
+		}
+		document, ok := previous[keys[index]]
+		if !ok {
+			text, err := converted[index].emitYAML()
+			if err != nil {
+				return "", "", 0, fmt.Errorf(ErrOutputEncode, OutputFormatYAML, err)
+			}
+			document = convertedDocument{output: text, warn: converted[index].warn}
+		}
+		current[keys[index]] = document
+		output += document.output
+		warn += document.warn
+	}
+	c.files[inputFile] = current
+	return output, warn, len(changed), nil
+}
+
+// Forget drops the documents of inputFile, such as after it was removed.
+func (c *IncrementalConverter) Forget(inputFile string) {
+	delete(c.files, inputFile)
+}
@@ -0,0 +1,232 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+func TestIncrementalConverter(t *testing.T) {
+	document := func(name, property string) string {
+		return fmt.Sprintf(`apiVersion: v1
+kind: Secret
+metadata:
+  name: %s
+  annotations:
+    avp.kubernetes.io/path: "secret/data/%s"
+type: Opaque
+stringData:
+  %s: <%s>
+`, name, name, property, property)
+	}
+	noPlaceholder := `apiVersion: v1
+kind: Secret
+metadata:
+  name: plain
+  annotations:
+    avp.kubernetes.io/path: "secret/data/plain"
+type: Opaque
+stringData:
+  user: admin
+`
+
+	tests := []struct {
+		name            string
+		content         string
+		expectConverted int
+		expectErr       string
+	}{
+		{
+			name:            "first conversion",
+			content:         document("app", "user") + "---\n" + document("db", "password") + "---\n" + noPlaceholder,
+			expectConverted: 3,
+		},
+		{
+			name:            "unchanged",
+			content:         document("app", "user") + "---\n" + document("db", "password") + "---\n" + noPlaceholder,
+			expectConverted: 0,
+		},
+		{
+			name:            "one document changed",
+			content:         document("app", "user") + "---\n" + document("db", "admin") + "---\n" + noPlaceholder,
+			expectConverted: 1,
+		},
+		{
+			name:      "broken document",
+			content:   document("app", "user") + "---\n" + strings.Replace(document("db", "admin"), "secret/data/db", "db", 1),
+			expectErr: "illegal vault path",
+		},
+		{
+			name:            "broken document fixed",
+			content:         document("app", "user") + "---\n" + document("db", "admin") + "---\n" + noPlaceholder,
+			expectConverted: 0,
+		},
+	}
+
+	file := filepath.Join(t.TempDir(), "secrets.yaml")
+	converter, err := NewIncrementalConverter(SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, ConvertOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(file, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			output, warn, converted, err := converter.ConvertFile(file)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Fatalf("error mismatch: got: %v, want: %s", err, tt.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if converted != tt.expectConverted {
+				t.Errorf("converted documents mismatch: got: %d, want: %d", converted, tt.expectConverted)
+			}
+
+			expectOutput, expectWarn, err := ConvertSecretFile(file, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, ConvertOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != expectOutput || warn != expectWarn {
+				t.Errorf("output mismatch: got:\n%s%s\nwant:\n%s%s", warn, output, expectWarn, expectOutput)
+			}
+		})
+	}
+
+	if _, err := NewIncrementalConverter(SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil,
+		ConvertOptions{Format: OutputFormatJSON}); err == nil || err.Error() != fmt.Sprintf(ErrIncrementalFormat, OutputFormatJSON) {
+		t.Errorf("expect an error for the json format, got: %v", err)
+	}
+}
+
+func TestIncrementalConverterStrict(t *testing.T) {
+	body := `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  annotations:
+    avp.kubernetes.io/path: "secret/data/app"
+type: Opaque
+stringData:
+  user: <user>
+  password: < password >
+`
+	file := filepath.Join(t.TempDir(), "secrets.yaml")
+	if err := os.WriteFile(file, []byte(body), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	converter, err := NewIncrementalConverter(SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, ConvertOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, _, err := converter.ConvertFile(file); err != nil {
+		t.Fatalf("unexpected error without strict: %v", err)
+	}
+
+	strict, err := NewIncrementalConverter(SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, ConvertOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _, _, err = strict.ConvertFile(file)
+	var placeholderErr *PlaceholderError
+	if !errors.As(err, &placeholderErr) {
+		t.Fatalf("expect a PlaceholderError, got %v", err)
+	}
+	if len(placeholderErr.Occurrences) != 1 || placeholderErr.Occurrences[0].Pos.String() != file+":10:13" {
+		t.Errorf("expect one near-miss at %s:10:13, got %v", file, err)
+	}
+}
+
+func TestIncrementalConverterCache(t *testing.T) {
+	setBuildVersion(t, "test")
+	body := `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  annotations:
+    avp.kubernetes.io/path: "secret/data/app"
+type: Opaque
+stringData:
+  user: <user>
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  annotations:
+    avp.kubernetes.io/path: "secret/data/db"
+type: Opaque
+stringData:
+  password: <password>
+`
+	file := filepath.Join(t.TempDir(), "secrets.yaml")
+	if err := os.WriteFile(file, []byte(body), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cache, err := NewConvertCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a new watch converts nothing the last one cached
+	var outputs []string
+	for run := 0; run < 2; run++ {
+		converter, err := NewIncrementalConverter(SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, ConvertOptions{Cache: cache})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		output, _, _, err := converter.ConvertFile(file)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		outputs = append(outputs, output)
+	}
+	if cache.Misses != 2 || cache.Hits != 2 {
+		t.Errorf("expect 2 misses then 2 hits, got %d misses and %d hits", cache.Misses, cache.Hits)
+	}
+	if outputs[0] != outputs[1] {
+		t.Errorf("cached output mismatch: got:\n%s\nwant:\n%s", outputs[1], outputs[0])
+	}
+}
+
+func TestIncrementalConverterMissingValues(t *testing.T) {
+	document := func(name, variable string) string {
+		return fmt.Sprintf(`apiVersion: v1
+kind: Secret
+metadata:
+  name: %s
+  annotations:
+    avp.kubernetes.io/path: "secret/data/<%% %s %%>/%s"
+type: Opaque
+stringData:
+  user: <user>
+`, name, variable, name)
+	}
+	file := filepath.Join(t.TempDir(), "secrets.yaml")
+	if err := os.WriteFile(file, []byte(document("app", "APP_ENV")+"---\n"+document("db", "DB_ENV")), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	converter, err := NewIncrementalConverter(SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, MapResolver{}, ConvertOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, _, _, err = converter.ConvertFile(file)
+	var missingErr *MissingValuesError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("expect a MissingValuesError, got %v", err)
+	}
+	if strings.Join(missingErr.Names, ",") != "APP_ENV,DB_ENV" {
+		t.Errorf("expect the values of both documents missing, got %v", missingErr.Names)
+	}
+}
@@ -0,0 +1,267 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+const InventoryFormatCSV = "csv"
+
+// InventoryFormats are the formats of the inventory.
+var InventoryFormats = []string{InventoryFormatCSV, OutputFormatJSON}
+
+// InventoryEntry is a vault property the ExternalSecret of a secret key reads.
+type InventoryEntry struct {
+	File      string `json:"file"`
+	Namespace string `json:"namespace"`
+	Secret    string `json:"secret"`
+	Key       string `json:"key"`
+	Mount     string `json:"mount"`
+	// Path is relative to the mount, as in remoteRef.key.
+	Path     string `json:"path"`
+	Property string `json:"property"`
+	Version  string `json:"version,omitempty"`
+}
+
+// PathUsage lists the secrets, as namespace/name, reading a vault path.
+type PathUsage struct {
+	Mount   string   `json:"mount"`
+	Path    string   `json:"path"`
+	Secrets []string `json:"secrets"`
+}
+
+// InventoryFilter keeps the entries matching all its non-empty fields, path.Match
+// patterns.
+type InventoryFilter struct {
+	Namespace string
+	Secret    string
+	Path      string
+	Property  string
+}
+
+// InventoryFile returns the vault properties read for the secrets of
+// inputFile, the secrets without placeholders read none.
+func InventoryFile(inputFile string, resolver Resolver) ([]InventoryEntry, error) {
+	body, err := os.ReadFile(inputFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading inputSecret file: %w", err)
+	}
+	return inventorySecretContent(inputFile, body, resolver)
+}
+
+func inventorySecretContent(file string, input []byte, resolver Resolver) ([]InventoryEntry, error) {
+	explanations, err := explainSecretContent(file, input, SecretStoreType, "", esv1beta1.CreatePolicyOwner, resolver, ConvertOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []InventoryEntry
+	for _, explanation := range explanations {
+		if explanation.Err != nil {
+			return nil, explanation.Err
+		}
+		if explanation.Skipped != "" {
+			continue
+		}
+		defaultMount, err := getVaultMount(explanation.ResolvedPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range explanation.Keys {
+			mounts, err := referenceMounts(key.Value, resolver)
+			if err != nil {
+				return nil, err
+			}
+			for _, data := range key.Data {
+				mount, ok := mounts[data.RemoteRef.Key]
+				if !ok {
+					mount = defaultMount
+				}
+				entries = append(entries, InventoryEntry{
+					File:      file,
+					Namespace: explanation.Namespace,
+					Secret:    explanation.Name,
+					Key:       key.Key,
+					Mount:     mount,
+					Path:      data.RemoteRef.Key,
+					Property:  data.RemoteRef.Property,
+					Version:   data.RemoteRef.Version,
+				})
+			}
+		}
+	}
+	return entries, nil
+}
+
+// referenceMounts returns the mount of every vault path referenced by the
+// <path:...> placeholders of value, by path relative to its mount.
+func referenceMounts(value string, resolver Resolver) (map[string]string, error) {
+	if resolver != nil {
+		var err error
+		if value, err = resolved(value, resolver); err != nil {
+			return nil, err
+		}
+	}
+	mounts := make(map[string]string)
+	for _, p := range findPlaceholders(value) {
+		name := p.name
+		if !strings.HasPrefix(name, pathReferencePrefix) {
+			continue
+		}
+		ref, err := parsePathReference(name)
+		if err != nil {
+			return nil, err
+		}
+		secretPath := strings.Split(strings.TrimPrefix(name, pathReferencePrefix), "#")[0]
+		if mounts[ref.key], err = getVaultMount(secretPath); err != nil {
+			return nil, err
+		}
+	}
+	return mounts, nil
+}
+
+// Match reports whether entry matches the filter.
+func (f InventoryFilter) Match(entry InventoryEntry) bool {
+	for _, field := range []struct{ pattern, value string }{
+		{f.Namespace, entry.Namespace},
+		{f.Secret, entry.Secret},
+		{f.Path, entry.Path},
+		{f.Property, entry.Property},
+	} {
+		if field.pattern == "" {
+			continue
+		}
+		if ok, err := path.Match(field.pattern, field.value); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify returns an error for a malformed pattern of the filter.
+func (f InventoryFilter) Verify() error {
+	for _, pattern := range []string{f.Namespace, f.Secret, f.Path, f.Property} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf(ErrInventoryIllegalPattern, pattern)
+		}
+	}
+	return nil
+}
+
+// FilterInventory returns the entries matching filter.
+func FilterInventory(entries []InventoryEntry, filter InventoryFilter) []InventoryEntry {
+	var kept []InventoryEntry
+	for _, entry := range entries {
+		if filter.Match(entry) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// InventoryIndex returns the secrets reading every vault path, sorted by mount
+// and path.
+func InventoryIndex(entries []InventoryEntry) []PathUsage {
+	type mountPath struct{ mount, path string }
+	secrets := make(map[mountPath]map[string]bool)
+	for _, entry := range entries {
+		key := mountPath{entry.Mount, entry.Path}
+		if secrets[key] == nil {
+			secrets[key] = make(map[string]bool)
+		}
+		secrets[key][qualifiedName(entry.Namespace, entry.Secret)] = true
+	}
+
+	index := make([]PathUsage, 0, len(secrets))
+	for key, names := range secrets {
+		usage := PathUsage{Mount: key.mount, Path: key.path}
+		for name := range names {
+			usage.Secrets = append(usage.Secrets, name)
+		}
+		sort.Strings(usage.Secrets)
+		index = append(index, usage)
+	}
+	sort.Slice(index, func(i, j int) bool {
+		if index[i].Mount != index[j].Mount {
+			return index[i].Mount < index[j].Mount
+		}
+		return index[i].Path < index[j].Path
+	})
+	return index
+}
+
+func qualifiedName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// VerifyInventoryFormat returns an error for an unknown format, the empty
+// format is CSV.
+func VerifyInventoryFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, known := range InventoryFormats {
+		if format == known {
+			return nil
+		}
+	}
+	return fmt.Errorf(ErrOutputUnknownFormat, format, strings.Join(InventoryFormats, ", "))
+}
+
+// FormatInventory emits the entries as CSV with a header or a JSON array.
+func FormatInventory(entries []InventoryEntry, format string) (string, error) {
+	if err := VerifyInventoryFormat(format); err != nil {
+		return "", err
+	}
+	if format == OutputFormatJSON {
+		if entries == nil {
+			entries = []InventoryEntry{}
+		}
+		body, err := marshalJSON(entries, true)
+		return string(body), err
+	}
+	rows := [][]string{{"file", "namespace", "secret", "key", "mount", "path", "property", "version"}}
+	for _, entry := range entries {
+		rows = append(rows, []string{entry.File, entry.Namespace, entry.Secret, entry.Key,
+			entry.Mount, entry.Path, entry.Property, entry.Version})
+	}
+	return writeCSV(rows)
+}
+
+// FormatInventoryIndex emits the index as CSV with a row per path and secret
+// or a JSON array.
+func FormatInventoryIndex(index []PathUsage, format string) (string, error) {
+	if err := VerifyInventoryFormat(format); err != nil {
+		return "", err
+	}
+	if format == OutputFormatJSON {
+		body, err := marshalJSON(index, true)
+		return string(body), err
+	}
+	rows := [][]string{{"mount", "path", "secret"}}
+	for _, usage := range index {
+		for _, secret := range usage.Secrets {
+			rows = append(rows, []string{usage.Mount, usage.Path, secret})
+		}
+	}
+	return writeCSV(rows)
+}
+
+func writeCSV(rows [][]string) (string, error) {
+	var out bytes.Buffer
+	writer := csv.NewWriter(&out)
+	if err := writer.WriteAll(rows); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
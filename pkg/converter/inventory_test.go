@@ -0,0 +1,154 @@
+package converter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInventorySecretContent(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  namespace: team
+  annotations:
+    avp.kubernetes.io/path: "kv/data/<% ENV %>/app"
+type: Opaque
+stringData:
+  url: https://<host>/db
+  admin: <path:secret/data/shared/admin#user#2>
+  static: plain
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: plain
+  annotations:
+    avp.kubernetes.io/path: "kv/data/plain"
+type: Opaque
+stringData:
+  user: admin
+`)
+	entries, err := inventorySecretContent("secrets.yaml", body, MapResolver{"ENV": "prod"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := []InventoryEntry{
+		{File: "secrets.yaml", Namespace: "team", Secret: "app", Key: "url", Mount: "kv", Path: "prod/app", Property: "host"},
+		{File: "secrets.yaml", Namespace: "team", Secret: "app", Key: "admin", Mount: "secret", Path: "shared/admin", Property: "user", Version: "2"},
+	}
+	if diff := cmp.Diff(expect, entries); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestInventoryFilter(t *testing.T) {
+	entries := []InventoryEntry{
+		{Namespace: "team", Secret: "app", Mount: "kv", Path: "prod/app", Property: "host"},
+		{Namespace: "team", Secret: "db", Mount: "kv", Path: "prod/db", Property: "password"},
+		{Namespace: "other", Secret: "app", Mount: "kv", Path: "prod/app", Property: "password"},
+	}
+	tests := []struct {
+		name   string
+		filter InventoryFilter
+		expect []string
+	}{
+		{name: "no filter", expect: []string{"team/app", "team/db", "other/app"}},
+		{name: "namespace", filter: InventoryFilter{Namespace: "team"}, expect: []string{"team/app", "team/db"}},
+		{name: "path pattern", filter: InventoryFilter{Path: "prod/a*"}, expect: []string{"team/app", "other/app"}},
+		{name: "all fields", filter: InventoryFilter{Secret: "app", Property: "pass*"}, expect: []string{"other/app"}},
+		{name: "no match", filter: InventoryFilter{Path: "dev/*"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, entry := range FilterInventory(entries, tt.filter) {
+				got = append(got, qualifiedName(entry.Namespace, entry.Secret))
+			}
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("FilterInventory() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if err := (InventoryFilter{Path: "prod/["}).Verify(); err == nil {
+		t.Errorf("expect an error for a malformed pattern")
+	}
+}
+
+func TestInventoryIndex(t *testing.T) {
+	entries := []InventoryEntry{
+		{Namespace: "team", Secret: "db", Mount: "kv", Path: "prod/db", Property: "password"},
+		{Namespace: "team", Secret: "app", Mount: "kv", Path: "prod/app", Property: "host"},
+		{Namespace: "team", Secret: "app", Mount: "kv", Path: "prod/app", Property: "port"},
+		{Secret: "legacy", Mount: "kv", Path: "prod/app", Property: "host"},
+		{Namespace: "team", Secret: "app", Mount: "secret", Path: "shared/admin", Property: "user"},
+	}
+	expect := []PathUsage{
+		{Mount: "kv", Path: "prod/app", Secrets: []string{"legacy", "team/app"}},
+		{Mount: "kv", Path: "prod/db", Secrets: []string{"team/db"}},
+		{Mount: "secret", Path: "shared/admin", Secrets: []string{"team/app"}},
+	}
+	index := InventoryIndex(entries)
+	if diff := cmp.Diff(expect, index); diff != "" {
+		t.Errorf("InventoryIndex() mismatch (-want +got):\n%s", diff)
+	}
+
+	csv, err := FormatInventoryIndex(index, InventoryFormatCSV)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectCSV := "mount,path,secret\nkv,prod/app,legacy\nkv,prod/app,team/app\nkv,prod/db,team/db\nsecret,shared/admin,team/app\n"
+	if csv != expectCSV {
+		t.Errorf("FormatInventoryIndex() = %q, want %q", csv, expectCSV)
+	}
+}
+
+func TestFormatInventory(t *testing.T) {
+	entries := []InventoryEntry{
+		{File: "secrets.yaml", Namespace: "team", Secret: "app", Key: "conf, main", Mount: "kv", Path: "prod/app", Property: "host", Version: "2"},
+	}
+	tests := []struct {
+		format    string
+		entries   []InventoryEntry
+		expect    string
+		expectErr bool
+	}{
+		{
+			format:  "",
+			entries: entries,
+			expect:  "file,namespace,secret,key,mount,path,property,version\nsecrets.yaml,team,app,\"conf, main\",kv,prod/app,host,2\n",
+		},
+		{
+			format:  OutputFormatJSON,
+			entries: entries,
+			expect: `[
+  {
+    "file": "secrets.yaml",
+    "namespace": "team",
+    "secret": "app",
+    "key": "conf, main",
+    "mount": "kv",
+    "path": "prod/app",
+    "property": "host",
+    "version": "2"
+  }
+]
+`,
+		},
+		{format: OutputFormatJSON, expect: "[]\n"},
+		{format: OutputFormatYAML, expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := FormatInventory(tt.entries, tt.format)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expect {
+				t.Errorf("FormatInventory() mismatch:\n%s", cmp.Diff(tt.expect, got))
+			}
+		})
+	}
+}
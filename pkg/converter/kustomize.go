@@ -0,0 +1,176 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+)
+
+// KustomizeOverlay is a target cluster of the kustomize layout, the empty
+// fields keep the value of the base.
+type KustomizeOverlay struct {
+	Name            string `json:"-" yaml:"-"`
+	StoreType       string `json:"storeType,omitempty" yaml:"storeType"`
+	StoreName       string `json:"storeName,omitempty" yaml:"storeName"`
+	RefreshInterval string `json:"refreshInterval,omitempty" yaml:"refreshInterval"`
+}
+
+// LoadKustomizeOverlays reads an overlays file mapping every cluster to the
+// store and refresh interval of its ExternalSecrets:
+//
+//	prod:
+//	  storeType: ClusterSecretStore
+//	  storeName: vault-prod
+//	  refreshInterval: 1h
+//
+// The overlays are returned in the order of the file.
+func LoadKustomizeOverlays(path string) ([]KustomizeOverlay, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading overlays file: %w", err)
+	}
+
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(body, &root); err != nil {
+		return nil, fmt.Errorf("error parsing overlays file %s: %w", path, err)
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yamlv3.MappingNode || len(root.Content[0].Content) == 0 {
+		return nil, fmt.Errorf(ErrKustomizeEmpty, path)
+	}
+
+	mapping := root.Content[0]
+	var overlays []KustomizeOverlay
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		var overlay KustomizeOverlay
+		if err := mapping.Content[i+1].Decode(&overlay); err != nil {
+			return nil, fmt.Errorf("error parsing overlay %s of %s: %w", mapping.Content[i].Value, path, err)
+		}
+		overlay.Name = mapping.Content[i].Value
+		if err := overlay.verify(); err != nil {
+			return nil, fmt.Errorf("%w in overlays file %s", err, path)
+		}
+		overlays = append(overlays, overlay)
+	}
+	return overlays, nil
+}
+
+func (o KustomizeOverlay) verify() error {
+	if !isMatrixEnvironmentName(o.Name) {
+		return fmt.Errorf(ErrKustomizeIllegalOverlay, o.Name)
+	}
+	if o.StoreType != "" && o.StoreType != SecretStoreType && o.StoreType != ClusterSecretStoreType {
+		return fmt.Errorf(illegalStoreType, o.StoreType)
+	}
+	if o.RefreshInterval != "" {
+		if _, err := time.ParseDuration(o.RefreshInterval); err != nil {
+			return fmt.Errorf(ErrKustomizeIllegalRefreshInterval, o.RefreshInterval, o.Name)
+		}
+	}
+	return nil
+}
+
+// kustomization is the subset of kustomize.config.k8s.io/v1beta1 Kustomization
+// the layout needs.
+type kustomization struct {
+	APIVersion string               `json:"apiVersion"`
+	Kind       string               `json:"kind"`
+	Resources  []string             `json:"resources"`
+	Patches    []kustomizationPatch `json:"patches,omitempty"`
+}
+
+type kustomizationPatch struct {
+	Target kustomizationTarget `json:"target"`
+	Patch  string              `json:"patch"`
+}
+
+type kustomizationTarget struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+type jsonPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+// patch is the JSON patch applied to every ExternalSecret of the overlay, nil
+// when the overlay keeps the base as is.
+func (o KustomizeOverlay) patch() []jsonPatchOperation {
+	var operations []jsonPatchOperation
+	if o.StoreName != "" {
+		operations = append(operations, jsonPatchOperation{Op: "replace", Path: "/spec/secretStoreRef/name", Value: o.StoreName})
+	}
+	if o.StoreType != "" {
+		operations = append(operations, jsonPatchOperation{Op: "replace", Path: "/spec/secretStoreRef/kind", Value: o.StoreType})
+	}
+	if o.RefreshInterval != "" {
+		operations = append(operations, jsonPatchOperation{Op: "replace", Path: "/spec/refreshInterval", Value: o.RefreshInterval})
+	}
+	return operations
+}
+
+// WriteKustomize writes a kustomize layout to dir: base/ holds the converted
+// ExternalSecrets, resources maps their file names to their content, and
+// overlays/<name>/ patches them for every overlay.
+func WriteKustomize(dir string, resources map[string]string, overlays []KustomizeOverlay) error {
+	baseDir := filepath.Join(dir, "base")
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return err
+	}
+
+	var names []string
+	for name, content := range resources {
+		if err := os.WriteFile(filepath.Join(baseDir, name), []byte(content), 0o644); err != nil {
+			return err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if err := writeKustomization(baseDir, kustomization{Resources: names}); err != nil {
+		return err
+	}
+
+	for _, overlay := range overlays {
+		if err := overlay.verify(); err != nil {
+			return err
+		}
+		operations := overlay.patch()
+		overlayKustomization := kustomization{Resources: []string{"../../base"}}
+		if len(operations) != 0 {
+			patch, err := yaml.Marshal(operations)
+			if err != nil {
+				return fmt.Errorf("error encoding patch of overlay %s: %w", overlay.Name, err)
+			}
+			overlayKustomization.Patches = []kustomizationPatch{{
+				Target: kustomizationTarget{Group: "external-secrets.io", Version: "v1beta1", Kind: "ExternalSecret"},
+				Patch:  string(patch),
+			}}
+		}
+
+		overlayDir := filepath.Join(dir, "overlays", overlay.Name)
+		if err := os.MkdirAll(overlayDir, 0o755); err != nil {
+			return err
+		}
+		if err := writeKustomization(overlayDir, overlayKustomization); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeKustomization(dir string, k kustomization) error {
+	k.APIVersion = "kustomize.config.k8s.io/v1beta1"
+	k.Kind = "Kustomization"
+	body, err := yaml.Marshal(k)
+	if err != nil {
+		return fmt.Errorf("error encoding kustomization: %w", err)
+	}
+	return os.WriteFile(filepath.Join(dir, "kustomization.yaml"), body, 0o644)
+}
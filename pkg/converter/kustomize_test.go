@@ -0,0 +1,140 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadKustomizeOverlays(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		expect []KustomizeOverlay
+		err    func(path string) error
+	}{
+		{
+			name: "overlays in file order",
+			body: `prod:
+  storeType: ClusterSecretStore
+  storeName: vault-prod
+  refreshInterval: 1h
+dev:
+  storeName: vault-dev
+`,
+			expect: []KustomizeOverlay{
+				{Name: "prod", StoreType: ClusterSecretStoreType, StoreName: "vault-prod", RefreshInterval: "1h"},
+				{Name: "dev", StoreName: "vault-dev"},
+			},
+		},
+		{
+			name: "empty file",
+			body: "",
+			err: func(path string) error {
+				return fmt.Errorf(ErrKustomizeEmpty, path)
+			},
+		},
+		{
+			name: "illegal store type",
+			body: "prod:\n  storeType: VaultStore\n",
+			err: func(path string) error {
+				return fmt.Errorf("%w in overlays file %s", fmt.Errorf(illegalStoreType, "VaultStore"), path)
+			},
+		},
+		{
+			name: "illegal refresh interval",
+			body: "prod:\n  refreshInterval: daily\n",
+			err: func(path string) error {
+				return fmt.Errorf("%w in overlays file %s", fmt.Errorf(ErrKustomizeIllegalRefreshInterval, "daily", "prod"), path)
+			},
+		},
+		{
+			name: "illegal overlay name",
+			body: "../prod:\n  storeName: vault\n",
+			err: func(path string) error {
+				return fmt.Errorf("%w in overlays file %s", fmt.Errorf(ErrKustomizeIllegalOverlay, "../prod"), path)
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "overlays.yaml")
+			if err := os.WriteFile(path, []byte(tt.body), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			overlays, err := LoadKustomizeOverlays(path)
+			if tt.err != nil {
+				if err == nil || err.Error() != tt.err(path).Error() {
+					t.Errorf("LoadKustomizeOverlays() error mismatch: got: %v, want: %v", err, tt.err(path))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expect, overlays); diff != "" {
+				t.Errorf("overlays mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteKustomize(t *testing.T) {
+	dir := t.TempDir()
+	resources := map[string]string{"secrets.yaml": "kind: ExternalSecret\n"}
+	overlays := []KustomizeOverlay{
+		{Name: "prod", StoreType: ClusterSecretStoreType, StoreName: "vault-prod", RefreshInterval: "1h"},
+		{Name: "dev"},
+	}
+	if err := WriteKustomize(dir, resources, overlays); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expect := map[string]string{
+		filepath.Join("base", "secrets.yaml"): "kind: ExternalSecret\n",
+		filepath.Join("base", "kustomization.yaml"): `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- secrets.yaml
+`,
+		filepath.Join("overlays", "prod", "kustomization.yaml"): `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+patches:
+- patch: |
+    - op: replace
+      path: /spec/secretStoreRef/name
+      value: vault-prod
+    - op: replace
+      path: /spec/secretStoreRef/kind
+      value: ClusterSecretStore
+    - op: replace
+      path: /spec/refreshInterval
+      value: 1h
+  target:
+    group: external-secrets.io
+    kind: ExternalSecret
+    version: v1beta1
+resources:
+- ../../base
+`,
+		filepath.Join("overlays", "dev", "kustomization.yaml"): `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+- ../../base
+`,
+	}
+	for name, content := range expect {
+		body, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(body) != content {
+			t.Errorf("%s mismatch: got:\n%s\nwant:\n%s", name, body, content)
+		}
+	}
+}
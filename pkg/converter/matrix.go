@@ -0,0 +1,72 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// MatrixEnvironment is an environment of a matrix file with the values of its
+// <% VAR %> placeholders.
+type MatrixEnvironment struct {
+	Name   string
+	Values ValueSource
+}
+
+// LoadMatrixFile reads a matrix file mapping every environment to its values:
+//
+//	dev:
+//	  ENV: dev
+//	prod:
+//	  ENV: prod
+//
+// The environments are returned in the order of the file.
+func LoadMatrixFile(path string) ([]MatrixEnvironment, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading matrix file: %w", err)
+	}
+
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(body, &root); err != nil {
+		return nil, fmt.Errorf("error parsing matrix file %s: %w", path, err)
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yamlv3.MappingNode || len(root.Content[0].Content) == 0 {
+		return nil, fmt.Errorf(ErrMatrixEmpty, path)
+	}
+
+	mapping := root.Content[0]
+	var environments []MatrixEnvironment
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		name := mapping.Content[i].Value
+		if !isMatrixEnvironmentName(name) {
+			return nil, fmt.Errorf(ErrMatrixIllegalEnvironment, name, path)
+		}
+		sourceName := fmt.Sprintf("%s[%s]", path, name)
+		values, err := valuesFromMapping(sourceName, mapping.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		environments = append(environments, MatrixEnvironment{
+			Name:   name,
+			Values: ValueSource{Name: sourceName, Values: values},
+		})
+	}
+	return environments, nil
+}
+
+// isMatrixEnvironmentName reports whether name can be used as an output
+// directory of its own.
+func isMatrixEnvironmentName(name string) bool {
+	return name != "" && name != "." && name != ".." &&
+		!strings.ContainsAny(name, `/\`) && filepath.Base(name) == name
+}
+
+// MatrixOutputPath is where the ExternalSecrets converted from inputFile are
+// written for an environment.
+func MatrixOutputPath(outputDir, environment, inputFile string) string {
+	return filepath.Join(outputDir, environment, filepath.Base(inputFile))
+}
@@ -0,0 +1,116 @@
+package converter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadMatrixFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		expect []MatrixEnvironment
+		err    func(path string) error
+	}{
+		{
+			name: "environments keep the file order",
+			body: `prod:
+  ENV: prod
+  REGION: us-east-1
+dev:
+  ENV: dev
+stage: {}
+`,
+			expect: []MatrixEnvironment{
+				{Name: "prod", Values: ValueSource{Values: map[string]string{"ENV": "prod", "REGION": "us-east-1"}}},
+				{Name: "dev", Values: ValueSource{Values: map[string]string{"ENV": "dev"}}},
+				{Name: "stage", Values: ValueSource{Values: map[string]string{}}},
+			},
+		},
+		{
+			name: "empty matrix",
+			body: ``,
+			err: func(path string) error {
+				return fmt.Errorf(ErrMatrixEmpty, path)
+			},
+		},
+		{
+			name: "environment escaping the output dir",
+			body: "../prod:\n  ENV: prod\n",
+			err: func(path string) error {
+				return fmt.Errorf(ErrMatrixIllegalEnvironment, "../prod", path)
+			},
+		},
+		{
+			name: "nested values",
+			body: "dev:\n  ENV:\n    name: dev\n",
+			err: func(path string) error {
+				return fmt.Errorf(ErrValuesNotScalar, "ENV", path+"[dev]")
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	for idx, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("matrix-%d.yaml", idx))
+			if err := os.WriteFile(path, []byte(tt.body), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			environments, err := LoadMatrixFile(path)
+			if tt.err != nil {
+				if err == nil || err.Error() != tt.err(path).Error() {
+					t.Errorf("LoadMatrixFile() error mismatch: got: %v, want: %v", err, tt.err(path))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for idx := range tt.expect {
+				tt.expect[idx].Values.Name = fmt.Sprintf("%s[%s]", path, tt.expect[idx].Name)
+			}
+			if diff := cmp.Diff(tt.expect, environments); diff != "" {
+				t.Errorf("environments mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMatrixEnvironmentResolved(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "secrets.yaml")
+	body := `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  annotations:
+    avp.kubernetes.io/path: "secret/data/<% ENV %>/app"
+type: Opaque
+stringData:
+  user: <USER>
+`
+	if err := os.WriteFile(input, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, env := range []string{"dev", "prod"} {
+		resolver := NewLayeredResolver(ValueSource{Name: env, Values: map[string]string{"ENV": env}})
+		output, _, err := ConvertSecretFile(input, SecretStoreType, "test", esv1beta1.CreatePolicyOwner, resolver, ConvertOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if want := "key: " + env + "/app\n"; !strings.Contains(output, want) {
+			t.Errorf("expect %q in output of %s:\n%s", want, env, output)
+		}
+		if got, want := MatrixOutputPath("out", env, input), filepath.Join("out", env, "secrets.yaml"); got != want {
+			t.Errorf("MatrixOutputPath() mismatch: got: %s, want: %s", got, want)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The placeholders of AVP, any other angle bracket is literal text:
+//
+//	<name>                          a property of the avp.kubernetes.io/path
+//	<path:mount/data/path#property> a property of any path, #version may follow
+//
+// A name has only letters, digits, '_', '-' and '.', both may hold <% VAR %>
+// placeholders while unresolved and be padded with spaces.
+var (
+	patternEnvInPlaceholder = `<%(?:[^%]|%[^>])*%>`
+	patternPlaceholderName  = `(?:[A-Za-z0-9_.\-]|` + patternEnvInPlaceholder + `)+`
+	patternPathPlaceholder  = `path:(?:[^\s<>]|` + patternEnvInPlaceholder + `)+`
+	placeholderPattern      = regexp.MustCompile(`<[ \t]*(` + patternPathPlaceholder + `|` + patternPlaceholderName + `)[ \t]*>`)
+
+	// markupPattern is the content of an XML or HTML tag with attributes or
+	// self-closing, <a href="x"> or <br/>.
+	markupPattern = regexp.MustCompile(`^[A-Za-z][\w:.\-]*(?:\s+[\w:.\-]+\s*=\s*(?:"[^"]*"|'[^']*'|[^\s"'=<>]+))*\s*/?$`)
+	unclosedName  = regexp.MustCompile(`^[A-Za-z0-9_.\-]+[ \t]*(?:\r?\n|$)`)
+	xmlName       = regexp.MustCompile(`^[A-Za-z_][\w.\-]*$`)
+)
+
+// literalNames are placeholder names that are more likely literal text of a
+// config file than vault properties.
+var literalNames = map[string]bool{"none": true, "nil": true, "null": true, "empty": true, "unset": true}
+
+// placeholder is an AVP placeholder found in a value, offset and end are its
+// byte range in the value.
+type placeholder struct {
+	// name is the content of the angle brackets without the padding.
+	name   string
+	padded bool
+	offset int
+	end    int
+}
+
+// findPlaceholders returns the AVP placeholders of s in order. The <% VAR %>
+// placeholders are not part of them, nor are the tags of the XML elements of
+// s, <user>...</user>, and the candidates that are literal text.
+func findPlaceholders(s string) []placeholder {
+	var placeholders []placeholder
+	for _, p := range placeholderCandidates(s) {
+		if !p.literal() {
+			placeholders = append(placeholders, p)
+		}
+	}
+	return placeholders
+}
+
+// placeholderCandidates returns the matches of the placeholder grammar in s
+// outside of <% VAR %> placeholders and XML element tags, in order.
+func placeholderCandidates(s string) []placeholder {
+	envs := findEnvPlaceholders(s)
+	locs := placeholderPattern.FindAllStringSubmatchIndex(s, -1)
+	tags := elementTags(s, locs)
+	var placeholders []placeholder
+	for _, loc := range locs {
+		name := s[loc[2]:loc[3]]
+		if withinEnvPlaceholder(envs, loc[0]) || tags[loc[0]] {
+			continue
+		}
+		placeholders = append(placeholders, placeholder{
+			name:   name,
+			padded: loc[2]-loc[0] != 1 || loc[1]-loc[3] != 1,
+			offset: loc[0],
+			end:    loc[1],
+		})
+	}
+	return placeholders
+}
+
+// literal reports whether the candidate p is more likely literal text than a
+// placeholder: padded, as in a < b > c, or a name such as <none>. Only
+// --strict reports them.
+func (p placeholder) literal() bool {
+	return p.padded || literalNames[strings.ToLower(p.name)]
+}
+
+// hasPlaceholder reports whether s has an AVP or a <% VAR %> placeholder.
+func hasPlaceholder(s string) bool {
+	return len(findPlaceholders(s)) > 0 || resolvedValueFromEnv.MatchString(s)
+}
+
+// placeholderRanges returns the byte ranges of the AVP placeholders of s and
+// of the <% VAR %> placeholders outside of them, in order.
+func placeholderRanges(s string) [][2]int {
+	var ranges [][2]int
+	for _, p := range findPlaceholders(s) {
+		ranges = append(ranges, [2]int{p.offset, p.end})
+	}
+	for _, env := range findEnvPlaceholders(s) {
+		if !withinRanges(ranges, env.offset) {
+			ranges = append(ranges, [2]int{env.offset, env.end})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	return ranges
+}
+
+func withinRanges(ranges [][2]int, offset int) bool {
+	for _, r := range ranges {
+		if offset >= r[0] && offset < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func withinEnvPlaceholder(envs []envPlaceholder, offset int) bool {
+	for _, env := range envs {
+		if offset > env.offset && offset < env.end {
+			return true
+		}
+	}
+	return false
+}
+
+// elementTags returns the offsets of the placeholder matches locs that open
+// an XML element of s. A closing tag pairs with the last open tag of its
+// name, unless it directly follows it while an earlier one is open:
+// <password><password></password> is an element around a placeholder.
+func elementTags(s string, locs [][]int) map[int]bool {
+	type tag struct {
+		offset int
+		end    int
+		name   string
+	}
+	var events []tag
+	names := make(map[string]bool)
+	for _, loc := range locs {
+		name := s[loc[2]:loc[3]]
+		if loc[3]-loc[2] != loc[1]-loc[0]-2 || !xmlName.MatchString(name) {
+			continue
+		}
+		events = append(events, tag{offset: loc[0], end: loc[1], name: name})
+		names[name] = true
+	}
+	for name := range names {
+		closing := "</" + name + ">"
+		for from := 0; ; {
+			idx := strings.Index(s[from:], closing)
+			if idx == -1 {
+				break
+			}
+			events = append(events, tag{offset: from + idx, end: -1, name: name})
+			from += idx + len(closing)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].offset < events[j].offset })
+
+	tags := make(map[int]bool)
+	open := make(map[string][]tag)
+	for _, event := range events {
+		stack := open[event.name]
+		switch {
+		case event.end != -1:
+			open[event.name] = append(stack, event)
+		case len(stack) == 0:
+		case stack[len(stack)-1].end == event.offset && len(stack) > 1:
+			tags[stack[len(stack)-2].offset] = true
+			open[event.name] = append(stack[:len(stack)-2], stack[len(stack)-1])
+		default:
+			tags[stack[len(stack)-1].offset] = true
+			open[event.name] = stack[:len(stack)-1]
+		}
+	}
+	return tags
+}
+
+// closedElement reports whether the tag name opened before from is closed in
+// s after it.
+func closedElement(s string, from int, name string) bool {
+	return strings.Contains(s[from:], "</"+name+">")
+}
+
+// PlaceholderError lists the suspicious near-misses of placeholders found in
+// strict mode, so they can be fixed at once.
+type PlaceholderError struct {
+	Occurrences []*SourceError
+}
+
+func (e *PlaceholderError) Error() string {
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf(ErrStrictPlaceholders, len(e.Occurrences)))
+	for _, occurrence := range e.Occurrences {
+		msg.WriteString("\n  ")
+		msg.WriteString(occurrence.Error())
+	}
+	return msg.String()
+}
+
+func (e *PlaceholderError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Occurrences))
+	for _, occurrence := range e.Occurrences {
+		errs = append(errs, occurrence)
+	}
+	return errs
+}
+
+// checkPlaceholders reports the near-misses of placeholders in the values of
+// the AVP secrets, the angle brackets the conversion leaves as literal text
+// while they were likely meant as placeholders.
+func checkPlaceholders(inputSecrets []internalSecret) error {
+	placeholderErr := &PlaceholderError{}
+	for idx := range inputSecrets {
+		placeholderErr.Occurrences = append(placeholderErr.Occurrences, secretNearMisses(&inputSecrets[idx])...)
+	}
+	if len(placeholderErr.Occurrences) == 0 {
+		return nil
+	}
+	return placeholderErr
+}
+
+// secretNearMisses returns the near-misses of placeholders in the values of
+// inputSecret, none when it is not an AVP secret.
+func secretNearMisses(inputSecret *internalSecret) []*SourceError {
+	if inputSecret.Annotations["avp.kubernetes.io/path"] == "" {
+		return nil
+	}
+	var occurrences []*SourceError
+	for _, field := range []string{sourceFieldData, sourceFieldStringData} {
+		values := inputSecret.Data
+		if field == sourceFieldStringData {
+			values = inputSecret.StringData
+		}
+		for _, key := range inputSecret.orderedKeys(field, values) {
+			for _, miss := range nearMisses(values[key]) {
+				err := inputSecret.sourceErrorAt(field, key, miss.offset, miss.err)
+				occurrences = append(occurrences, err.(*SourceError))
+			}
+		}
+	}
+	return occurrences
+}
+
+// nearMisses returns the angle brackets of s that look like a placeholder
+// but are not one, or are one only by accident.
+func nearMisses(s string) []*placeholderError {
+	var misses []*placeholderError
+	miss := func(offset int, format, text string) {
+		misses = append(misses, &placeholderError{offset: offset, err: fmt.Errorf(format, text)})
+	}
+
+	placeholders := placeholderCandidates(s)
+	covered := make([]bool, len(s))
+	for _, p := range placeholders {
+		for i := p.offset; i < p.end; i++ {
+			covered[i] = true
+		}
+	}
+	for _, env := range findEnvPlaceholders(s) {
+		for i := env.offset; i < env.end; i++ {
+			covered[i] = true
+		}
+	}
+
+	next := 0
+	for i := 0; i < len(s); i++ {
+		if next < len(placeholders) && placeholders[next].offset == i {
+			p := placeholders[next]
+			next++
+			switch {
+			case p.padded:
+				miss(i, ErrPlaceholderPadded, s[p.offset:p.end])
+			case literalNames[strings.ToLower(p.name)]:
+				miss(i, ErrPlaceholderLiteral, s[p.offset:p.end])
+			}
+			continue
+		}
+		if s[i] != '<' || covered[i] {
+			continue
+		}
+
+		rest := s[i+1:]
+		end := strings.IndexAny(rest, "<>\n")
+		if end == -1 || rest[end] != '>' {
+			// <USER or <path:secret/data/app#user at the end of a line
+			if strings.HasPrefix(rest, pathReferencePrefix) || unclosedName.MatchString(rest) {
+				miss(i, ErrPlaceholderUnclosed, nearMissText(rest, end))
+			}
+			continue
+		}
+		content := rest[:end]
+		trimmed := strings.TrimSpace(content)
+		text := "<" + content + ">"
+		switch {
+		case trimmed == "" || strings.ContainsAny(content[:1], "/!? \t"):
+			// </tag>, <!-- comment -->, <?xml ?> or a comparison, a < b > c
+		case strings.HasPrefix(trimmed, "%") || strings.HasSuffix(trimmed, "%"):
+			miss(i, ErrPlaceholderEnv, text)
+		case strings.HasPrefix(strings.ToLower(trimmed), "path"):
+			// <PATH:...>, <path secret/data/app#user> or spaces in a path
+			miss(i, ErrPlaceholderPath, text)
+		case markupPattern.MatchString(content) || closedElement(s, i+1+end+1, strings.Fields(content)[0]):
+			// <a href="x">, <br/> or <td class>...</td>
+		default:
+			miss(i, ErrPlaceholderIllegalName, text)
+		}
+	}
+	return misses
+}
+
+// nearMissText is the unclosed placeholder starting rest, up to the end of
+// its line.
+func nearMissText(rest string, end int) string {
+	if end == -1 {
+		end = len(rest)
+	}
+	if line := strings.IndexByte(rest, '\n'); line != -1 && line < end {
+		end = line
+	}
+	return "<" + strings.TrimRight(rest[:end], " \t\r")
+}
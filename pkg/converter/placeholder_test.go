@@ -0,0 +1,218 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindPlaceholders(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		expect []string
+	}{
+		{
+			name:   "names",
+			value:  "<user>:<pass.word-1>@<host_name>",
+			expect: []string{"user", "pass.word-1", "host_name"},
+		},
+		{
+			name:   "path references",
+			value:  "<path:secret/data/app#user>/<path:kv/data/<% ENV %>/db#password#2>",
+			expect: []string{"path:secret/data/app#user", "path:kv/data/<% ENV %>/db#password#2"},
+		},
+		{
+			name:   "env placeholders",
+			value:  `<% REGION | default "<eu>" %>-<<% ENV %>_PASSWD>`,
+			expect: []string{"<% ENV %>_PASSWD"},
+		},
+		{
+			name:  "xml",
+			value: `<?xml version="1.0"?><config><user>admin</user><!-- note --><br/></config>`,
+		},
+		{
+			name:   "xml with placeholders",
+			value:  "<datasource><password><db_password></password></datasource>",
+			expect: []string{"db_password"},
+		},
+		{
+			name:  "comparisons and html",
+			value: `if a < b && c > d { return } <a href="/x">link</a> <db password> <user@host>`,
+		},
+		{
+			name:   "nested xml",
+			value:  "<a><a></a></a><user><user></user><user>x</user> <user>",
+			expect: []string{"user", "user"},
+		},
+		{
+			name:  "padded brackets are text",
+			value: "a < b > c, < user >, <\tpath:secret/data/app#user >",
+		},
+		{
+			name:  "literal names are text",
+			value: "image: <none>, <NULL>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range findPlaceholders(tt.value) {
+				got = append(got, p.name)
+			}
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("findPlaceholders() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNearMisses(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		expect []string
+	}{
+		{
+			name:  "placeholders and literal text",
+			value: `<user>:<path:secret/data/app#pass> <% ENV %> a < b <?xml?><a href="x"></a><br/><td class>x</td>`,
+		},
+		{
+			name:   "padded",
+			value:  "user = < user >",
+			expect: []string{"< user > is not a placeholder, it is padded with spaces"},
+		},
+		{
+			name:   "literal names",
+			value:  "tag: <none>",
+			expect: []string{"<none> is not a placeholder, it looks like literal text"},
+		},
+		{
+			name:  "illegal names",
+			value: "<db password> <user@host> <$TOKEN>",
+			expect: []string{
+				"<db password> is not a placeholder, a name has only letters, digits, '_', '-' and '.'",
+				"<user@host> is not a placeholder, a name has only letters, digits, '_', '-' and '.'",
+				"<$TOKEN> is not a placeholder, a name has only letters, digits, '_', '-' and '.'",
+			},
+		},
+		{
+			name:  "path",
+			value: "<PATH:secret/data/app#user> <path secret/data/app#user>",
+			expect: []string{
+				"<PATH:secret/data/app#user> is not a <path:mount/data/path#property> placeholder",
+				"<path secret/data/app#user> is not a <path:mount/data/path#property> placeholder",
+			},
+		},
+		{
+			name:  "env",
+			value: "<% ENV > <ENV %> <% my-env %>",
+			expect: []string{
+				"<% ENV > is not a <% VAR %> placeholder",
+				"<ENV %> is not a <% VAR %> placeholder",
+				"<% my-env %> is not a <% VAR %> placeholder",
+			},
+		},
+		{
+			name:   "unclosed",
+			value:  "user = <USER\npass = <PASS>\nif a<b c {}\n<Resource name=\"<name>\"/>",
+			expect: []string{"<USER is not a placeholder, it is not closed by '>'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, miss := range nearMisses(tt.value) {
+				if !strings.HasPrefix(tt.value[miss.offset:], "<") {
+					t.Errorf("near-miss %q does not point to a '<': %q", miss.err, tt.value[miss.offset:])
+				}
+				got = append(got, miss.err.Error())
+			}
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("nearMisses() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertLiteralAngleBrackets(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  annotations:
+    avp.kubernetes.io/path: "secret/data/app"
+type: Opaque
+stringData:
+  config.xml: |
+    <config>
+      <user><user></user>
+      <check>a < b</check>
+      <pass>< db password ></pass>
+    </config>
+`)
+	out, _, err := convertSecretContent("input.yaml", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, ConvertOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expect := range []string{"<user>{{ .user }}</user>", "<check>a < b</check>", "<pass>< db password ></pass>"} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("expect %q in output:\n%s", expect, out)
+		}
+	}
+
+	// the literal brackets are no near-misses either
+	if _, _, err := convertSecretContent("input.yaml", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, ConvertOptions{Strict: true}); err != nil {
+		t.Fatalf("unexpected error in strict mode: %v", err)
+	}
+
+	strict := strings.Replace(string(body), "<check>a < b</check>", "<check><PATH:secret/data/app#check></check>", 1)
+	_, _, err = convertSecretContent("input.yaml", []byte(strict), SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, ConvertOptions{Strict: true})
+	var placeholderErr *PlaceholderError
+	if !errors.As(err, &placeholderErr) {
+		t.Fatalf("expect a PlaceholderError, got %v", err)
+	}
+	if len(placeholderErr.Occurrences) != 1 || placeholderErr.Occurrences[0].Pos.String() != "input.yaml:12:14" {
+		t.Errorf("expect one near-miss at input.yaml:12:14, got %v", err)
+	}
+}
+
+func TestConvertPaddedAndLiteralNames(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  annotations:
+    avp.kubernetes.io/path: "secret/data/app"
+type: Opaque
+stringData:
+  user: <user>
+  check: a < b > c
+  image: <none>
+`)
+	out, _, err := convertSecretContent("input.yaml", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, ConvertOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expect := range []string{"check: a < b > c", "image: <none>", "property: user"} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("expect %q in output:\n%s", expect, out)
+		}
+	}
+	for _, absent := range []string{"property: b", "property: none"} {
+		if strings.Contains(out, absent) {
+			t.Errorf("expect no %q in output:\n%s", absent, out)
+		}
+	}
+
+	_, _, err = convertSecretContent("input.yaml", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, ConvertOptions{Strict: true})
+	var placeholderErr *PlaceholderError
+	if !errors.As(err, &placeholderErr) || len(placeholderErr.Occurrences) != 2 {
+		t.Fatalf("expect the two near-misses in strict mode, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	PolicyGroupStore     = "store"
+	PolicyGroupNamespace = "namespace"
+)
+
+// PolicyGroups are the ways of grouping the vault paths into policies.
+var PolicyGroups = []string{PolicyGroupStore, PolicyGroupNamespace}
+
+// VaultPolicy is a Vault ACL policy reading exactly the KV v2 paths of a store.
+type VaultPolicy struct {
+	Name      string
+	Store     string
+	Namespace string
+	// Paths are the full data/ and metadata/ paths, sorted.
+	Paths []string
+}
+
+// VaultPolicies groups the vault paths of the entries into a policy for the
+// store storeName or one per namespace of it. A path is read from data/, and
+// from metadata/ too when a version of it is pinned.
+func VaultPolicies(entries []InventoryEntry, storeName, groupBy string) ([]VaultPolicy, error) {
+	if err := VerifyPolicyGroup(groupBy); err != nil {
+		return nil, err
+	}
+
+	policies := make(map[string]*VaultPolicy)
+	paths := make(map[string]map[string]bool)
+	for _, entry := range entries {
+		if strings.Contains(entry.Mount+entry.Path, "<") {
+			return nil, fmt.Errorf(ErrPolicyUnresolvedPath, entry.Mount, entry.Path, qualifiedName(entry.Namespace, entry.Secret))
+		}
+		policy := VaultPolicy{Name: storeName, Store: storeName}
+		if groupBy == PolicyGroupNamespace && entry.Namespace != "" {
+			policy.Name = storeName + "-" + entry.Namespace
+			policy.Namespace = entry.Namespace
+		}
+		if policies[policy.Name] == nil {
+			policies[policy.Name] = &policy
+			paths[policy.Name] = make(map[string]bool)
+		}
+		paths[policy.Name][entry.Mount+"/data/"+entry.Path] = true
+		if entry.Version != "" {
+			paths[policy.Name][entry.Mount+"/metadata/"+entry.Path] = true
+		}
+	}
+
+	sorted := make([]VaultPolicy, 0, len(policies))
+	for name, policy := range policies {
+		for path := range paths[name] {
+			policy.Paths = append(policy.Paths, path)
+		}
+		sort.Strings(policy.Paths)
+		sorted = append(sorted, *policy)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted, nil
+}
+
+// VerifyPolicyGroup returns an error for an unknown grouping, the empty one
+// is per store.
+func VerifyPolicyGroup(groupBy string) error {
+	if groupBy == "" {
+		return nil
+	}
+	for _, known := range PolicyGroups {
+		if groupBy == known {
+			return nil
+		}
+	}
+	return fmt.Errorf(ErrPolicyUnknownGroup, groupBy, strings.Join(PolicyGroups, ", "))
+}
+
+// HCL returns the policy in the HCL of Vault, granting read on every path.
+func (p VaultPolicy) HCL() string {
+	var out strings.Builder
+	if p.Namespace == "" {
+		out.WriteString(fmt.Sprintf("# policy %s of store %s\n", p.Name, p.Store))
+	} else {
+		out.WriteString(fmt.Sprintf("# policy %s of store %s in namespace %s\n", p.Name, p.Store, p.Namespace))
+	}
+	for _, path := range p.Paths {
+		out.WriteString(fmt.Sprintf("\npath %q {\n  capabilities = [\"read\"]\n}\n", path))
+	}
+	return out.String()
+}
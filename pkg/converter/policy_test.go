@@ -0,0 +1,104 @@
+package converter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVaultPolicies(t *testing.T) {
+	entries := []InventoryEntry{
+		{Namespace: "team", Secret: "app", Mount: "kv", Path: "prod/app", Property: "host"},
+		{Namespace: "team", Secret: "app", Mount: "kv", Path: "prod/app", Property: "port"},
+		{Namespace: "team", Secret: "app", Mount: "secret", Path: "shared/admin", Property: "user", Version: "2"},
+		{Namespace: "other", Secret: "db", Mount: "kv", Path: "prod/db", Property: "password"},
+		{Secret: "legacy", Mount: "kv", Path: "legacy", Property: "token"},
+	}
+	tests := []struct {
+		name      string
+		entries   []InventoryEntry
+		groupBy   string
+		expect    []VaultPolicy
+		expectErr error
+	}{
+		{
+			name:    "per store",
+			entries: entries,
+			expect: []VaultPolicy{{
+				Name:  "vault",
+				Store: "vault",
+				Paths: []string{"kv/data/legacy", "kv/data/prod/app", "kv/data/prod/db", "secret/data/shared/admin", "secret/metadata/shared/admin"},
+			}},
+		},
+		{
+			name:    "per namespace",
+			entries: entries,
+			groupBy: PolicyGroupNamespace,
+			expect: []VaultPolicy{
+				{Name: "vault", Store: "vault", Paths: []string{"kv/data/legacy"}},
+				{Name: "vault-other", Store: "vault", Namespace: "other", Paths: []string{"kv/data/prod/db"}},
+				{
+					Name:      "vault-team",
+					Store:     "vault",
+					Namespace: "team",
+					Paths:     []string{"kv/data/prod/app", "secret/data/shared/admin", "secret/metadata/shared/admin"},
+				},
+			},
+		},
+		{
+			name:    "no paths",
+			groupBy: PolicyGroupStore,
+			expect:  []VaultPolicy{},
+		},
+		{
+			name:      "unresolved path",
+			entries:   []InventoryEntry{{Namespace: "team", Secret: "app", Mount: "kv", Path: "<% ENV %>/app", Property: "host"}},
+			expectErr: fmt.Errorf(ErrPolicyUnresolvedPath, "kv", "<% ENV %>/app", "team/app"),
+		},
+		{
+			name:      "unknown grouping",
+			groupBy:   "cluster",
+			expectErr: fmt.Errorf(ErrPolicyUnknownGroup, "cluster", "store, namespace"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies, err := VaultPolicies(tt.entries, "vault", tt.groupBy)
+			if tt.expectErr != nil {
+				if err == nil || err.Error() != tt.expectErr.Error() {
+					t.Fatalf("expect error %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expect, policies); diff != "" {
+				t.Errorf("VaultPolicies() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVaultPolicyHCL(t *testing.T) {
+	policy := VaultPolicy{
+		Name:      "vault-team",
+		Store:     "vault",
+		Namespace: "team",
+		Paths:     []string{"kv/data/prod/app", "kv/metadata/prod/app"},
+	}
+	expect := `# policy vault-team of store vault in namespace team
+
+path "kv/data/prod/app" {
+  capabilities = ["read"]
+}
+
+path "kv/metadata/prod/app" {
+  capabilities = ["read"]
+}
+`
+	if got := policy.HCL(); got != expect {
+		t.Errorf("HCL() mismatch:\n%s", cmp.Diff(expect, got))
+	}
+}
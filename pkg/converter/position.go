@@ -0,0 +1,332 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Position is a 1-based line and column in an input file.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// IsValid reports whether the position points at a line of the input.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		return p.File
+	}
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// SourceError is a placeholder error pinned to the secret and data key
+// it was found in.
+type SourceError struct {
+	Pos    Position
+	Secret string
+	Key    string
+	Err    error
+
+	// line is the source line at Pos, used to render Snippet.
+	line string
+}
+
+func (e *SourceError) Error() string {
+	var prefix string
+	if e.Pos.IsValid() {
+		prefix = e.Pos.String() + ": "
+	}
+	if e.Key == "" {
+		return fmt.Sprintf("%ssecret %s: %v", prefix, e.Secret, e.Err)
+	}
+	return fmt.Sprintf("%ssecret %s key %s: %v", prefix, e.Secret, e.Key, e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// Snippet renders the offending source line with a caret under the column,
+// it returns an empty string when the position is unknown.
+func (e *SourceError) Snippet() string {
+	if !e.Pos.IsValid() || e.line == "" {
+		return ""
+	}
+	gutter := fmt.Sprintf("%d", e.Pos.Line)
+	var caret strings.Builder
+	for i, char := range e.line {
+		if i >= e.Pos.Column-1 {
+			break
+		}
+		if char == '\t' {
+			caret.WriteRune('\t')
+		} else {
+			caret.WriteRune(' ')
+		}
+	}
+	return fmt.Sprintf("%s | %s\n%s | %s^\n", gutter, e.line, strings.Repeat(" ", len(gutter)), caret.String())
+}
+
+// placeholderError is returned by the placeholder parsers with the byte
+// offset of the offending placeholder within the parsed value.
+type placeholderError struct {
+	offset int
+	err    error
+}
+
+func (e *placeholderError) Error() string {
+	return e.err.Error()
+}
+
+func (e *placeholderError) Unwrap() error {
+	return e.err
+}
+
+const (
+	sourceFieldData        = "data"
+	sourceFieldStringData  = "stringData"
+	sourceFieldAnnotations = "metadata.annotations"
+)
+
+// secretSource records where a secret and its values were found in the input.
+type secretSource struct {
+	file  string
+	lines []string
+	doc   Position
+	// index is the 0-based index of the document in the input.
+	index int
+	// text is the document as written in the input.
+	text string
+	// values is keyed by field and key, see sourceKey.
+	values map[string]valueSource
+}
+
+// valueSource is the location of a scalar value, raw keeps the value as
+// parsed so offsets are only mapped while the value is still unchanged.
+// start.Line is relative to the document, lines maps it back to the input.
+type valueSource struct {
+	start  Position
+	lines  []int
+	block  bool
+	indent int
+	quoted bool
+	raw    string
+}
+
+func sourceKey(field, key string) string {
+	return field + "/" + key
+}
+
+// orderedKeys returns the keys of values, a field of the secret, in order of
+// appearance in the input, or sorted when the secret was not parsed from one.
+func (s *internalSecret) orderedKeys(field string, values map[string]string) []string {
+	keys := sortedKeys(values)
+	if s.source == nil {
+		return keys
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, aok := s.source.values[sourceKey(field, keys[i])]
+		b, bok := s.source.values[sourceKey(field, keys[j])]
+		if !aok || !bok {
+			return aok && !bok
+		}
+		if a.start.Line != b.start.Line {
+			return a.start.Line < b.start.Line
+		}
+		return a.start.Column < b.start.Column
+	})
+	return keys
+}
+
+// position returns the source position of the given offset in a value of the
+// secret, falling back to the value or document start when unknown.
+func (s *internalSecret) position(field, key string, offset int) Position {
+	if s.source == nil {
+		return Position{}
+	}
+	value, ok := s.source.values[sourceKey(field, key)]
+	if !ok {
+		return s.source.doc
+	}
+	if value.raw != s.valueOf(field, key) || offset < 0 {
+		offset = 0
+	}
+	return value.at(offset)
+}
+
+func (s *internalSecret) valueOf(field, key string) string {
+	switch field {
+	case sourceFieldData:
+		return s.Data[key]
+	case sourceFieldStringData:
+		return s.StringData[key]
+	case sourceFieldAnnotations:
+		return s.Annotations[key]
+	}
+	return ""
+}
+
+// sourceError pins err to the secret and key, picking up the offset from
+// placeholder errors.
+func (s *internalSecret) sourceError(field, key string, err error) error {
+	offset := 0
+	if pe, ok := err.(*placeholderError); ok {
+		offset = pe.offset
+	}
+	return s.sourceErrorAt(field, key, offset, err)
+}
+
+func (s *internalSecret) sourceErrorAt(field, key string, offset int, err error) error {
+	if _, ok := err.(*SourceError); ok {
+		return err
+	}
+	pos := s.position(field, key, offset)
+	srcErr := &SourceError{
+		Pos:    pos,
+		Secret: s.Name,
+		Key:    key,
+		Err:    err,
+	}
+	if s.source != nil && pos.IsValid() && pos.Line <= len(s.source.lines) {
+		srcErr.line = s.source.lines[pos.Line-1]
+	}
+	return srcErr
+}
+
+// explainRule records the rule that converted a key when explaining.
+func (s *internalSecret) explainRule(field, key, rule string) {
+	if s.rules != nil {
+		s.rules[sourceKey(field, key)] = rule
+	}
+}
+
+// documentPosition is where the secret starts in the input file.
+func (s *internalSecret) documentPosition() Position {
+	if s.source == nil {
+		return Position{}
+	}
+	return s.source.doc
+}
+
+func (v valueSource) at(offset int) Position {
+	if offset > len(v.raw) {
+		offset = len(v.raw)
+	}
+	before := v.raw[:offset]
+	newlines := strings.Count(before, "\n")
+	column := offset - (strings.LastIndex(before, "\n") + 1)
+	pos := v.start
+	pos.Line += newlines
+	switch {
+	case v.block:
+		pos.Column = v.indent + column + 1
+	case newlines == 0 && v.quoted:
+		pos.Column += column + 1
+	case newlines == 0:
+		pos.Column += column
+	default:
+		pos.Column = column + 1
+	}
+	if pos.Line > len(v.lines) {
+		pos.Line = v.start.Line
+	}
+	pos.Line = v.lines[pos.Line-1]
+	return pos
+}
+
+// locateSecret walks the yaml node tree of a document to record where the
+// secret and its data, stringData and avp annotations are defined.
+func locateSecret(file string, lines []string, doc yamlDocument) *secretSource {
+	source := &secretSource{
+		file:   file,
+		lines:  lines,
+		values: make(map[string]valueSource),
+	}
+	source.text = documentText(lines, doc)
+	docLines := strings.Split(doc.content, "\n")
+	originalLine := func(line int) int {
+		if line < 1 || line > len(doc.lines) {
+			return 0
+		}
+		return doc.lines[line-1]
+	}
+
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(doc.content), &root); err != nil ||
+		len(root.Content) == 0 || root.Content[0].Kind != yamlv3.MappingNode {
+		return source
+	}
+	top := root.Content[0]
+	source.doc = Position{File: file, Line: originalLine(top.Line), Column: top.Column}
+
+	record := func(field string, mapping *yamlv3.Node) {
+		if mapping == nil || mapping.Kind != yamlv3.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			key, value := mapping.Content[i], mapping.Content[i+1]
+			if value.Kind != yamlv3.ScalarNode {
+				continue
+			}
+			vs := valueSource{
+				start:  Position{File: file, Line: value.Line, Column: value.Column},
+				lines:  doc.lines,
+				quoted: value.Style&(yamlv3.DoubleQuotedStyle|yamlv3.SingleQuotedStyle) != 0,
+				raw:    value.Value,
+			}
+			if value.Style&(yamlv3.LiteralStyle|yamlv3.FoldedStyle) != 0 {
+				// block scalar content starts on the line after the indicator
+				vs.block = true
+				vs.start.Line++
+				if value.Line < len(docLines) {
+					next := docLines[value.Line]
+					vs.indent = len(next) - len(strings.TrimLeft(next, " "))
+				}
+			}
+			if originalLine(vs.start.Line) == 0 {
+				continue
+			}
+			source.values[sourceKey(field, key.Value)] = vs
+		}
+	}
+
+	record(sourceFieldData, mappingValue(top, "data"))
+	record(sourceFieldStringData, mappingValue(top, "stringData"))
+	record(sourceFieldAnnotations, mappingValue(mappingValue(top, "metadata"), "annotations"))
+	if name := mappingValue(mappingValue(top, "metadata"), "name"); name != nil {
+		source.doc = Position{File: file, Line: originalLine(name.Line), Column: name.Column}
+	}
+	return source
+}
+
+// documentText returns doc as written in the input lines.
+func documentText(lines []string, doc yamlDocument) string {
+	if len(doc.lines) == 0 {
+		return ""
+	}
+	first, last := doc.lines[0], doc.lines[len(doc.lines)-1]
+	return strings.Join(lines[first-1:last], "\n")
+}
+
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package converter
+
+import (
+	"errors"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+func TestSourceErrorPosition(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		resolve bool
+		strict  bool
+		expect  Position
+		key     string
+		snippet string
+	}{
+		{
+			name:   "unclosed placeholder in stringData block scalar",
+			strict: true,
+			body: `# leading comment
+apiVersion: v1
+kind: Secret
+metadata:
+  name: mysql
+  annotations:
+    avp.kubernetes.io/path: "secret/data/foo"
+type: Opaque
+stringData:
+  mylogin.conf: |
+    [client]
+    # comment inside the block
+    user = <USER>
+    password = <MYSQL_PASSWD
+`,
+			expect: Position{File: "input.yaml", Line: 14, Column: 16},
+			key:    "mylogin.conf",
+			snippet: `14 |     password = <MYSQL_PASSWD
+   |                ^
+`,
+		},
+		{
+			name:   "illegal placeholder name in quoted stringData",
+			strict: true,
+			body: `---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: other
+  annotations:
+    avp.kubernetes.io/path: "secret/data/foo"
+type: Opaque
+data:
+  user: <USER>
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: quoted
+  annotations:
+    avp.kubernetes.io/path: "secret/data/foo"
+type: Opaque
+stringData:
+  url: "https://<HOST>:<$PORT>/path"
+`,
+			expect: Position{File: "input.yaml", Line: 20, Column: 24},
+			key:    "url",
+		},
+		{
+			name: "multiple placeholders in data",
+			body: `apiVersion: v1
+kind: Secret
+metadata:
+  name: data
+  annotations:
+    avp.kubernetes.io/path: "secret/data/foo"
+type: Opaque
+data:
+  key1: <admin>-<dist>
+`,
+			expect: Position{File: "input.yaml", Line: 9, Column: 17},
+			key:    "key1",
+		},
+		{
+			name:    "env not set in vault path",
+			resolve: true,
+			body: `apiVersion: v1
+kind: Secret
+metadata:
+  name: env
+  annotations:
+    avp.kubernetes.io/path: "secret/data/<% NOT_SET_SOURCE_ERROR %>"
+type: Opaque
+data:
+  key1: <admin>
+`,
+			expect: Position{File: "input.yaml", Line: 6, Column: 42},
+			key:    "avp.kubernetes.io/path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := convertSecretContent("input.yaml", []byte(tt.body), SecretStoreType, "test",
+				esv1beta1.CreatePolicyOwner, testResolver(tt.resolve, nil), ConvertOptions{Strict: tt.strict})
+			var sourceErr *SourceError
+			if !errors.As(err, &sourceErr) {
+				t.Fatalf("expect a SourceError, got: %v", err)
+			}
+			if sourceErr.Pos != tt.expect {
+				t.Errorf("position mismatch: got: %s, want: %s", sourceErr.Pos, tt.expect)
+			}
+			if sourceErr.Key != tt.key {
+				t.Errorf("key mismatch: got: %s, want: %s", sourceErr.Key, tt.key)
+			}
+			if tt.snippet != "" && sourceErr.Snippet() != tt.snippet {
+				t.Errorf("snippet mismatch: got:\n%s\nwant:\n%s", sourceErr.Snippet(), tt.snippet)
+			}
+		})
+	}
+}
+
+func TestWarningPosition(t *testing.T) {
+	body := `apiVersion: v1
+kind: Secret
+metadata:
+  # the name is on line 5
+  name: plain
+  annotations:
+    avp.kubernetes.io/path: "secret/data/foo"
+type: Opaque
+data:
+  key1: value
+`
+	_, warn, err := convertSecretContent("input.yaml", []byte(body), SecretStoreType, "test",
+		esv1beta1.CreatePolicyOwner, nil, ConvertOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expect := "Error: input.yaml:5:9: not include any angle brackets of secret: plain\n"
+	if warn != expect {
+		t.Errorf("warn mismatch: got: %q, want: %q", warn, expect)
+	}
+}
@@ -0,0 +1,162 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+// pathReferencePrefix starts an inline reference to a property of any vault
+// path, <path:secret/data/other#property> or <path:secret/data/other#property#version>.
+const pathReferencePrefix = "path:"
+
+var aliasIllegalChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// secretReference is a vault property referenced by a placeholder.
+type secretReference struct {
+	key      string
+	property string
+	version  string
+}
+
+// secretReferences collects the properties referenced by the placeholders of
+// a secret and gives each of them a unique SecretKey.
+type secretReferences struct {
+	defaultKey string
+	decoding   esv1beta1.ExternalSecretDecodingStrategy
+
+	// refs are in order of first appearance.
+	refs    []secretReference
+	names   map[string]secretReference
+	aliases map[secretReference]string
+}
+
+// newSecretReferences returns the references of a secret whose
+// avp.kubernetes.io/path is defaultKey.
+func newSecretReferences(defaultKey string, decoding esv1beta1.ExternalSecretDecodingStrategy) *secretReferences {
+	return &secretReferences{
+		defaultKey: defaultKey,
+		decoding:   decoding,
+		names:      make(map[string]secretReference),
+	}
+}
+
+// add records the property referenced by the placeholder name, the content of
+// the angle brackets.
+func (r *secretReferences) add(name string) error {
+	trimmed := strings.TrimSpace(name)
+	if _, ok := r.names[trimmed]; ok {
+		return nil
+	}
+
+	ref := secretReference{key: r.defaultKey, property: trimmed}
+	if strings.HasPrefix(trimmed, pathReferencePrefix) {
+		var err error
+		if ref, err = parsePathReference(trimmed); err != nil {
+			return err
+		}
+	}
+
+	r.names[trimmed] = ref
+	for _, existing := range r.refs {
+		if existing == ref {
+			return nil
+		}
+	}
+	r.refs = append(r.refs, ref)
+	r.aliases = nil
+	return nil
+}
+
+func parsePathReference(name string) (secretReference, error) {
+	parts := strings.Split(strings.TrimPrefix(name, pathReferencePrefix), "#")
+	if len(parts) < 2 || len(parts) > 3 || parts[1] == "" {
+		return secretReference{}, fmt.Errorf(ErrCommonIllegalPathReference, name)
+	}
+	key, err := getVaultSecretKey(parts[0])
+	if err != nil {
+		return secretReference{}, fmt.Errorf(ErrCommonIllegalPathReference, name)
+	}
+	ref := secretReference{key: key, property: parts[1]}
+	if len(parts) == 3 {
+		ref.version = parts[2]
+	}
+	return ref, nil
+}
+
+// assign gives every reference its SecretKey. A property keeps its name unless
+// another reference uses it too, the references of the default path come
+// first and the others are aliased after their path, so the aliases do not
+// depend on the order of the secret keys.
+func (r *secretReferences) assign() {
+	if r.aliases != nil {
+		return
+	}
+
+	sorted := append([]secretReference(nil), r.refs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if (a.key == r.defaultKey) != (b.key == r.defaultKey) {
+			return a.key == r.defaultKey
+		}
+		if a.key != b.key {
+			return a.key < b.key
+		}
+		if a.property != b.property {
+			return a.property < b.property
+		}
+		return a.version < b.version
+	})
+
+	r.aliases = make(map[secretReference]string)
+	taken := make(map[string]bool)
+	for _, ref := range sorted {
+		alias := ref.property
+		if taken[alias] {
+			alias = aliasIllegalChars.ReplaceAllString(ref.key, "_") + "_" + ref.property
+			if ref.version != "" {
+				alias += "_v" + ref.version
+			}
+			for base, n := alias, 2; taken[alias]; n++ {
+				alias = fmt.Sprintf("%s_%d", base, n)
+			}
+		}
+		taken[alias] = true
+		r.aliases[ref] = alias
+	}
+}
+
+// alias returns the SecretKey of the placeholder name, the name itself when it
+// was not recorded.
+func (r *secretReferences) alias(name string) string {
+	ref, ok := r.names[strings.TrimSpace(name)]
+	if !ok {
+		return name
+	}
+	r.assign()
+	return r.aliases[ref]
+}
+
+// data returns the ExternalSecretData of the references in order of first
+// appearance.
+func (r *secretReferences) data() []esv1beta1.ExternalSecretData {
+	r.assign()
+	var data []esv1beta1.ExternalSecretData
+	for _, ref := range r.refs {
+		data = append(data, esv1beta1.ExternalSecretData{
+			SecretKey: r.aliases[ref],
+			RemoteRef: esv1beta1.ExternalSecretDataRemoteRef{
+				ConversionStrategy: esv1beta1.ExternalSecretConversionDefault,
+				DecodingStrategy:   r.decoding,
+				MetadataPolicy:     esv1beta1.ExternalSecretMetadataPolicyNone,
+				Key:                ref.key,
+				Property:           ref.property,
+				Version:            ref.version,
+			},
+		})
+	}
+	return data
+}
@@ -0,0 +1,174 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMultiplePathReferences(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectData   []esv1beta1.ExternalSecretData
+		expectTmpl   map[string]string
+		expectErr    error
+		expectErrKey string
+	}{
+		{
+			name: "same property from several paths",
+			body: `stringData:
+  user: <user>
+  admin: <path:secret/data/admin#user>
+  conf: |
+    admin=<path:secret/data/admin#user>
+    previous=<path:secret/data/team/app#user#2>
+    password=<password>
+`,
+			expectData: []esv1beta1.ExternalSecretData{
+				stringDataRef("user", "foo", "user", ""),
+				stringDataRef("admin_user", "admin", "user", ""),
+				stringDataRef("team_app_user_v2", "team/app", "user", "2"),
+				stringDataRef("password", "foo", "password", ""),
+			},
+			expectTmpl: map[string]string{
+				"user":  `{{ .user }}`,
+				"admin": `{{ .admin_user }}`,
+				"conf":  "admin={{ .admin_user }}\nprevious={{ .team_app_user_v2 }}\npassword={{ .password }}\n",
+			},
+		},
+		{
+			name: "other paths without the default path",
+			body: `stringData:
+  b: <path:secret/data/b#user>
+  a: <path:secret/data/a#user>
+`,
+			expectData: []esv1beta1.ExternalSecretData{
+				stringDataRef("user", "a", "user", ""),
+				stringDataRef("b_user", "b", "user", ""),
+			},
+			expectTmpl: map[string]string{
+				"a": `{{ .user }}`,
+				"b": `{{ .b_user }}`,
+			},
+		},
+		{
+			name: "alias taken by a property",
+			body: `stringData:
+  user: <user>
+  admin_user: <admin_user>
+  admin: <path:secret/data/admin#user>
+`,
+			expectData: []esv1beta1.ExternalSecretData{
+				stringDataRef("user", "foo", "user", ""),
+				stringDataRef("admin_user", "foo", "admin_user", ""),
+				stringDataRef("admin_user_2", "admin", "user", ""),
+			},
+			expectTmpl: map[string]string{
+				"user":       `{{ .user }}`,
+				"admin_user": `{{ .admin_user }}`,
+				"admin":      `{{ .admin_user_2 }}`,
+			},
+		},
+		{
+			name: "path reference without property",
+			body: `stringData:
+  admin: <path:secret/data/admin>
+`,
+			expectErr:    fmt.Errorf(ErrCommonIllegalPathReference, "path:secret/data/admin"),
+			expectErrKey: "admin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the aliases must not depend on the iteration order of the keys
+			for i := 0; i < 10; i++ {
+				inputSecretList, err := parseUnstructuredSecret([]byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: multi-path
+  annotations:
+    avp.kubernetes.io/path: "secret/data/foo"
+type: Opaque
+` + tt.body))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				externalSecret, err := convertSecret2ExtSecret(inputSecretList[0], SecretStoreType, "test", esv1beta1.CreatePolicyOwner, nil)
+				if tt.expectErr != nil {
+					var sourceErr *SourceError
+					if !errors.As(err, &sourceErr) || sourceErr.Key != tt.expectErrKey || sourceErr.Err.Error() != tt.expectErr.Error() {
+						t.Fatalf("error mismatch: got: %v, want: %v", err, tt.expectErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				data := externalSecret.Spec.Data
+				sort.Slice(data, func(i, j int) bool { return data[i].SecretKey < data[j].SecretKey })
+				expectData := append([]esv1beta1.ExternalSecretData(nil), tt.expectData...)
+				sort.Slice(expectData, func(i, j int) bool { return expectData[i].SecretKey < expectData[j].SecretKey })
+				if diff := cmp.Diff(expectData, data); diff != "" {
+					t.Fatalf("data mismatch (-want +got):\n%s", diff)
+				}
+				if diff := cmp.Diff(tt.expectTmpl, externalSecret.Spec.Target.Template.Data); diff != "" {
+					t.Fatalf("template mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretReferencesPadded(t *testing.T) {
+	tests := []struct {
+		name       string
+		names      []string
+		expectData []esv1beta1.ExternalSecretData
+	}{
+		{
+			name:       "padded name",
+			names:      []string{" user ", "user"},
+			expectData: []esv1beta1.ExternalSecretData{stringDataRef("user", "foo", "user", "")},
+		},
+		{
+			name:       "padded path reference",
+			names:      []string{"\tpath:secret/data/admin#user ", "path:secret/data/admin#user"},
+			expectData: []esv1beta1.ExternalSecretData{stringDataRef("user", "admin", "user", "")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := newSecretReferences("foo", esv1beta1.ExternalSecretDecodeNone)
+			for _, name := range tt.names {
+				if err := refs.add(name); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if diff := cmp.Diff(tt.expectData, refs.data()); diff != "" {
+				t.Errorf("data mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func stringDataRef(secretKey, key, property, version string) esv1beta1.ExternalSecretData {
+	return esv1beta1.ExternalSecretData{
+		SecretKey: secretKey,
+		RemoteRef: esv1beta1.ExternalSecretDataRemoteRef{
+			ConversionStrategy: esv1beta1.ExternalSecretConversionDefault,
+			DecodingStrategy:   esv1beta1.ExternalSecretDecodeNone,
+			MetadataPolicy:     esv1beta1.ExternalSecretMetadataPolicyNone,
+			Key:                key,
+			Property:           property,
+			Version:            version,
+		},
+	}
+}
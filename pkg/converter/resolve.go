@@ -3,210 +3,282 @@ package converter
 import (
 	"bytes"
 	"fmt"
-	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
-	"unicode"
+
+	corev1 "k8s.io/api/core/v1"
 )
 
 var (
-	patternResolveFromEnv = `<%\s*(\w+)\s*%>`
+	// <% NAME %>, <% NAME | default "value" %> or <% NAME | required "message" %>
+	patternResolveFromEnv = `<%\s*(\w+)\s*(?:\|\s*(default|required)\s+"((?:[^"\\]|\\.)*)"\s*)?%>`
 	resolvedValueFromEnv  = regexp.MustCompile(patternResolveFromEnv)
+
+	// templateIdentifier is a SecretKey a template can refer to as a field.
+	templateIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	// pathPlaceholderPrefix is a <path:...> placeholder up to its '>', in any
+	// case so the near-misses keep their '#' too.
+	pathPlaceholderPrefix = regexp.MustCompile(`(?i)<[ \t]*path:(?:[^<>]|` + patternEnvInPlaceholder + `)*`)
 )
 
-func resolved(originalString string) (string, error) {
-	needResolvedStrings := resolvedValueFromEnv.FindAllStringSubmatch(originalString, -1)
-	for _, match := range needResolvedStrings {
-		if len(match) > 1 {
-			env := match[1]
-			envResolved := os.Getenv(env)
-			if envResolved == "" {
-				return "", fmt.Errorf(ErrCommonNotSetEnv, env)
-			}
-			originalString = strings.Replace(originalString, match[0], envResolved, -1)
+const (
+	envModifierDefault  = "default"
+	envModifierRequired = "required"
+)
+
+// envPlaceholder is a <% NAME %> placeholder found in a value, offset and end
+// are its byte range in the value.
+type envPlaceholder struct {
+	name     string
+	modifier string
+	argument string
+	offset   int
+	end      int
+}
+
+// findEnvPlaceholders returns the <% NAME %> placeholders of s in order.
+func findEnvPlaceholders(s string) []envPlaceholder {
+	var placeholders []envPlaceholder
+	for _, loc := range resolvedValueFromEnv.FindAllStringSubmatchIndex(s, -1) {
+		placeholders = append(placeholders, newEnvPlaceholder(s, loc))
+	}
+	return placeholders
+}
+
+func newEnvPlaceholder(s string, loc []int) envPlaceholder {
+	placeholder := envPlaceholder{
+		name:   s[loc[2]:loc[3]],
+		offset: loc[0],
+		end:    loc[1],
+	}
+	if loc[4] != -1 {
+		placeholder.modifier = s[loc[4]:loc[5]]
+		argument := s[loc[6]:loc[7]]
+		if unquoted, err := strconv.Unquote(`"` + argument + `"`); err == nil {
+			argument = unquoted
 		}
+		placeholder.argument = argument
 	}
-	return originalString, nil
+	return placeholder
 }
 
-func getVaultSecretKey(secretPath string) (string, error) {
-	parts := strings.Split(secretPath, "/")
+// resolve returns the value of the placeholder, falling back to its default.
+func (p envPlaceholder) resolve(resolver Resolver) (string, error) {
+	if value, _ := resolver.Lookup(p.name); value != "" {
+		return value, nil
+	}
+	switch p.modifier {
+	case envModifierDefault:
+		return p.argument, nil
+	case envModifierRequired:
+		return "", &placeholderError{offset: p.offset, err: fmt.Errorf(ErrCommonRequiredEnv, p.name, p.argument)}
+	}
+	return "", &placeholderError{offset: p.offset, err: fmt.Errorf(ErrCommonNotSetEnv, p.name)}
+}
 
-	index := -1
-	for i, part := range parts {
-		if part == "data" {
-			index = i
-			break
+func resolved(originalString string, resolver Resolver) (string, error) {
+	var result strings.Builder
+	last := 0
+	for _, placeholder := range findEnvPlaceholders(originalString) {
+		value, err := placeholder.resolve(resolver)
+		if err != nil {
+			return "", err
 		}
+		result.WriteString(originalString[last:placeholder.offset])
+		result.WriteString(value)
+		last = placeholder.end
 	}
+	result.WriteString(originalString[last:])
+	return result.String(), nil
+}
 
-	if index == -1 || index+1 >= len(parts) {
-		return "", fmt.Errorf(illegalVaultPath, secretPath)
+// MissingValuesError lists every <% VAR %> placeholder of the input that has
+// no value, so they can be fixed at once.
+type MissingValuesError struct {
+	Names       []string
+	Occurrences []*SourceError
+}
+
+func (e *MissingValuesError) Error() string {
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf(ErrCommonNotSetEnv, strings.Join(e.Names, ", ")))
+	for _, occurrence := range e.Occurrences {
+		msg.WriteString("\n  ")
+		msg.WriteString(occurrence.Error())
 	}
+	return msg.String()
+}
 
-	result := strings.Join(parts[index+1:], "/")
-	return result, nil
+func (e *MissingValuesError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Occurrences))
+	for _, occurrence := range e.Occurrences {
+		errs = append(errs, occurrence)
+	}
+	return errs
 }
 
-func resolveAngleBrackets(s string) (string, error) {
-	var result strings.Builder
-	var temp strings.Builder
-	inBracket := false
-	inPercentBracket := false
-
-	for i := 0; i < len(s); i++ {
-		char := rune(s[i])
-
-		// Check for <% ... %> pattern and leave it unmodified
-		if char == '<' && i+1 < len(s) && s[i+1] == '%' {
-			inPercentBracket = true
-			result.WriteRune(char)
-			result.WriteRune('%')
-			i++ // Skip the next '%'
-			continue
-		}
-		if inPercentBracket {
-			// Keep writing until we find %>
-			if char == '%' && i+1 < len(s) && s[i+1] == '>' {
-				result.WriteRune('%')
-				result.WriteRune('>')
-				i++ // Skip the next '>'
-				inPercentBracket = false
+// checkMissingValues looks up every <% VAR %> placeholder the conversion would
+// resolve and reports all the variables without a value.
+func checkMissingValues(inputSecrets []internalSecret, resolver Resolver) error {
+	missing := &MissingValuesError{}
+	seen := make(map[string]bool)
+	check := func(inputSecret *internalSecret, field, key, value string) {
+		for _, placeholder := range findEnvPlaceholders(value) {
+			_, err := placeholder.resolve(resolver)
+			if err == nil {
 				continue
 			}
-			result.WriteRune(char)
-			continue
-		}
-
-		switch char {
-		case '<':
-			if inBracket {
-				return s, fmt.Errorf(FileContentAngleBracketsParseSyntaxError, `nested or unclosed '<'`)
-			}
-			inBracket = true
-			result.WriteString("{{ .")
-		case '>':
-			if !inBracket {
-				return s, fmt.Errorf(FileContentAngleBracketsParseSyntaxError, `unpaired '>'`)
-			}
-			inBracket = false
-			trimmedVariable := strings.TrimSpace(temp.String())
-			result.WriteString(trimmedVariable)
-			result.WriteString(" }}")
-			temp.Reset()
-		case ' ', '\n', '\r': // Handle spaces and newlines
-			if inBracket {
-				temp.WriteRune(char)
-			} else {
-				result.WriteRune(char)
-			}
-		default:
-			if inBracket {
-				temp.WriteRune(char)
-			} else {
-				result.WriteRune(char)
+			if !seen[placeholder.name] {
+				seen[placeholder.name] = true
+				missing.Names = append(missing.Names, placeholder.name)
 			}
+			err = inputSecret.sourceError(field, key, err)
+			missing.Occurrences = append(missing.Occurrences, err.(*SourceError))
 		}
 	}
 
-	if inBracket {
-		return s, fmt.Errorf(FileContentAngleBracketsParseSyntaxError, `unclosed '<'`)
+	for idx := range inputSecrets {
+		inputSecret := &inputSecrets[idx]
+		if secretCommonVerify(*inputSecret) != nil {
+			continue
+		}
+		check(inputSecret, sourceFieldAnnotations, "avp.kubernetes.io/path", inputSecret.Annotations["avp.kubernetes.io/path"])
+		if inputSecret.Type == corev1.SecretTypeDockerConfigJson {
+			continue
+		}
+		for _, key := range sortedKeys(inputSecret.Data) {
+			check(inputSecret, sourceFieldData, key, inputSecret.Data[key])
+		}
+		for _, key := range sortedKeys(inputSecret.StringData) {
+			check(inputSecret, sourceFieldStringData, key, inputSecret.StringData[key])
+		}
 	}
 
-	return result.String(), nil
+	if len(missing.Names) == 0 {
+		return nil
+	}
+	return missing
 }
 
-func addQuotesCurlyBraces(input string) string {
-	var result []string
-	inCurlyBraces := false
-
-	lines := strings.Split(input, "\n")
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
-	for lineIndex, line := range lines {
-		processedLine := processLine(line, &inCurlyBraces)
+func getVaultSecretKey(secretPath string) (string, error) {
+	parts := strings.Split(secretPath, "/")
 
-		if lineIndex > 0 {
-			result = append(result, "\n")
+	index := -1
+	for i, part := range parts {
+		if part == "data" {
+			index = i
+			break
 		}
+	}
 
-		result = append(result, processedLine)
+	if index == -1 || index+1 >= len(parts) {
+		return "", fmt.Errorf(illegalVaultPath, secretPath)
 	}
 
-	return strings.Join(result, "")
+	result := strings.Join(parts[index+1:], "/")
+	return result, nil
 }
 
-func processLine(line string, inCurlyBraces *bool) string {
-	var result []string
-	var currentWord strings.Builder
-	var leadingSpaces strings.Builder
-	processingLeadingSpaces := true
-	inTemplateBraces := false // 新增：用于跟踪 <% %> 模板标记
-
-	for i, char := range line {
-		if processingLeadingSpaces && unicode.IsSpace(char) {
-			leadingSpaces.WriteRune(char)
-			continue
-		} else if processingLeadingSpaces {
-			processingLeadingSpaces = false
-			result = append(result, leadingSpaces.String())
+// getVaultMount returns the mount of a KV v2 vault path, the part before data.
+func getVaultMount(secretPath string) (string, error) {
+	parts := strings.Split(secretPath, "/")
+	for i, part := range parts {
+		if part == "data" && i+1 < len(parts) {
+			return strings.Join(parts[:i], "/"), nil
 		}
+	}
+	return "", fmt.Errorf(illegalVaultPath, secretPath)
+}
 
-		// Check if it is in curly brackets
-		if char == '{' && i > 0 && line[i-1] == '{' {
-			*inCurlyBraces = true
-		} else if char == '}' && i > 0 && line[i-1] == '}' && *inCurlyBraces {
-			*inCurlyBraces = false
-		}
+// resolveAngleBracketsWith turns the placeholders of s into template
+// expressions of the SecretKey alias returns for them, the literal text around
+// them, other angle brackets included, is escaped.
+func resolveAngleBracketsWith(s string, alias func(name string) string) string {
+	var result strings.Builder
+	for _, segment := range templateSegments(s, alias) {
+		result.WriteString(segment.text)
+	}
+	return result.String()
+}
 
-		// Determines whether it is in the <% %> template tag
-		if char == '<' && i < len(line)-1 && line[i+1] == '%' {
-			inTemplateBraces = true
-		} else if char == '%' && i < len(line)-1 && line[i+1] == '>' {
-			inTemplateBraces = false
-		}
+// templateSegment is a part of the template generated from a value, the
+// escaped literal text before a placeholder or its expression, offset is where
+// the part starts in the value.
+type templateSegment struct {
+	offset int
+	text   string
+}
 
-		if unicode.IsSpace(char) && !*inCurlyBraces && !inTemplateBraces {
-			if currentWord.Len() > 0 {
-				word := currentWord.String()
-				// Check if the whole word is within curly braces or template tags
-				if strings.Contains(word, "{{") && strings.Contains(word, "}}") {
-					result = append(result, `"`+word+`"`)
-				} else {
-					result = append(result, word)
-				}
-				currentWord.Reset()
-			}
-			result = append(result, string(char))
-		} else {
-			currentWord.WriteRune(char)
+// templateSegments returns the parts of the template resolveAngleBracketsWith
+// generates for s, in order.
+func templateSegments(s string, alias func(name string) string) []templateSegment {
+	var segments []templateSegment
+	last := 0
+	for _, p := range findPlaceholders(s) {
+		segments = append(segments, templateSegment{offset: last, text: escapeTemplateText(s[last:p.offset], true)})
+		name := p.name
+		if alias != nil {
+			name = alias(name)
 		}
+		segments = append(segments, templateSegment{offset: p.offset, text: templateExpression(name)})
+		last = p.end
 	}
+	return append(segments, templateSegment{offset: last, text: escapeTemplateText(s[last:], false)})
+}
 
-	// Process the last word
-	if currentWord.Len() > 0 {
-		word := currentWord.String()
-		if strings.Contains(word, "{{") && strings.Contains(word, "}}") {
-			result = append(result, `"`+word+`"`)
-		} else {
-			result = append(result, word)
-		}
+// templateExpression is the template expression of the SecretKey name,
+// {{ .name }} or {{ index . "db-user" }} when name is not an identifier.
+func templateExpression(name string) string {
+	if templateIdentifier.MatchString(name) {
+		return "{{ ." + name + " }}"
 	}
+	return "{{ index . " + strconv.Quote(name) + " }}"
+}
 
-	return strings.Join(result, "")
+// escapeTemplateText escapes the literal text of a template so ESO renders it
+// as is: {{ becomes {{ "{{" }}, and a { ending the text when beforeExpression,
+// as it would open the expression that follows. A lone }} is already literal.
+func escapeTemplateText(text string, beforeExpression bool) string {
+	escaped := strings.ReplaceAll(text, "{{", `{{ "{{" }}`)
+	if beforeExpression && strings.HasSuffix(escaped, "{") {
+		escaped = strings.TrimSuffix(escaped, "{") + `{{ "{" }}`
+	}
+	return escaped
 }
 
 func processCommented(input []byte) []byte {
+	output, _ := stripComments(input)
+	return output
+}
+
+// stripComments is processCommented that also returns, for every output
+// line, the 1-based line number it came from.
+func stripComments(input []byte) ([]byte, []int) {
 	var output []byte
+	var lineMap []int
 	lines := bytes.Split(input, []byte("\n"))
 
-	for _, line := range lines {
+	for lineIndex, line := range lines {
 		trimmedLine := bytes.TrimLeft(line, " \t")
 		if len(trimmedLine) == 0 || trimmedLine[0] != '#' {
-			if idx := bytes.IndexByte(line, '#'); idx != -1 {
+			if idx := inlineCommentIndex(line); idx != -1 {
 				line = line[:idx]
 			}
 			output = append(output, line...)
 			output = append(output, '\n')
+			lineMap = append(lineMap, lineIndex+1)
 		}
 	}
 
@@ -214,5 +286,24 @@ func processCommented(input []byte) []byte {
 		output = output[:len(output)-1]
 	}
 
-	return output
+	return output, lineMap
+}
+
+// inlineCommentIndex returns the index of the comment of line, the first '#'
+// outside of the <path:secret/data/foo#key> placeholders.
+func inlineCommentIndex(line []byte) int {
+	spans := pathPlaceholderPrefix.FindAllIndex(line, -1)
+	for idx, char := range line {
+		if char != '#' {
+			continue
+		}
+		inPath := false
+		for _, span := range spans {
+			inPath = inPath || idx >= span[0] && idx < span[1]
+		}
+		if !inPath {
+			return idx
+		}
+	}
+	return -1
 }
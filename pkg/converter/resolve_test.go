@@ -1,9 +1,13 @@
 package converter
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"strings"
 	"testing"
+	"text/template"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
 )
 
 func TestResolved(t *testing.T) {
@@ -66,16 +70,54 @@ func TestResolved(t *testing.T) {
 			originalString: "<%      NOTSETVAR  %>-linux",
 			err:            fmt.Errorf(ErrCommonNotSetEnv, "NOTSETVAR"),
 		},
+		{
+			name:           "default_when_not_set",
+			originalString: `secret/data/<% REGION | default "eu-west-1" %>/app`,
+			expectString:   "secret/data/eu-west-1/app",
+		},
+		{
+			name:           "default_ignored_when_set",
+			originalString: `secret/data/<%REGION|default "eu-west-1"%>/app`,
+			expectString:   "secret/data/us-east-1/app",
+			envs: map[string]string{
+				"REGION": "us-east-1",
+			},
+		},
+		{
+			name:           "default_with_escaped_quote_and_brackets",
+			originalString: `<% GREETING | default "say \"hi\" <%>" %>`,
+			expectString:   `say "hi" <%>`,
+		},
+		{
+			name:           "empty_default",
+			originalString: `<% SUFFIX | default "" %>-linux`,
+			expectString:   "-linux",
+		},
+		{
+			name:           "required_when_set",
+			originalString: `<% TENANT | required "tenant must be set" %>-linux`,
+			expectString:   "a-linux",
+			envs: map[string]string{
+				"TENANT": "a",
+			},
+		},
+		{
+			name:           "required_when_not_set",
+			originalString: `<% TENANT | required "tenant must be set" %>-linux`,
+			err:            fmt.Errorf(ErrCommonRequiredEnv, "TENANT", "tenant must be set"),
+		},
+		{
+			name:           "unknown_modifier_is_not_a_placeholder",
+			originalString: `<% TENANT | upper %>-linux`,
+			expectString:   `<% TENANT | upper %>-linux`,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for k, v := range tt.envs {
-				_ = os.Setenv(k, v)
-			}
-			out, err := resolved(tt.originalString)
+			out, err := resolved(tt.originalString, MapResolver(tt.envs))
 			if err != nil {
-				if err.Error() != tt.err.Error() {
+				if tt.err == nil || err.Error() != tt.err.Error() {
 					t.Errorf("resolved() returned an unexpected error: got: %v, want: %v", err, tt.err)
 				}
 			} else {
@@ -143,7 +185,6 @@ func TestResolveAngleBrackets(t *testing.T) {
 		name           string
 		originalString string
 		expectString   string
-		err            error
 	}{
 		{
 			name:           "simple",
@@ -158,17 +199,17 @@ func TestResolveAngleBrackets(t *testing.T) {
 		{
 			name:           "include_many_space_and_angle_brackets",
 			originalString: "<   A   >-linux",
-			expectString:   "{{ .A }}-linux",
+			expectString:   "<   A   >-linux",
 		},
 		{
 			name:           "include_many_space_and_angle_brackets_2",
 			originalString: "<A    >-linux",
-			expectString:   "{{ .A }}-linux",
+			expectString:   "<A    >-linux",
 		},
 		{
 			name:           "include_many_space_and_angle_brackets_3",
 			originalString: "<   A>-linux",
-			expectString:   "{{ .A }}-linux",
+			expectString:   "<   A>-linux",
 		},
 		{
 			name:           "angle_brackets_connected",
@@ -184,7 +225,6 @@ func TestResolveAngleBrackets(t *testing.T) {
 			name:           "illegal_angle_brackets",
 			originalString: "sn0rt-<A",
 			expectString:   "sn0rt-<A",
-			err:            fmt.Errorf(FileContentAngleBracketsParseSyntaxError, `unclosed '<'`),
 		},
 		{
 			name:           "multi_angle_brackets",
@@ -209,7 +249,12 @@ func TestResolveAngleBrackets(t *testing.T) {
 		{
 			name:           "nested_angle_brackets",
 			originalString: "password = <<%ENV%>_MYSQL_PASSWD>",
-			expectString:   "password = {{ .<%ENV%>_MYSQL_PASSWD }}",
+			expectString:   `password = {{ index . "<%ENV%>_MYSQL_PASSWD" }}`,
+		},
+		{
+			name:           "env_placeholder_with_default_kept",
+			originalString: `<% REGION | default "a>b" %>-<PASSWD>`,
+			expectString:   `<% REGION | default "a>b" %>-{{ .PASSWD }}`,
 		},
 		{
 			name: "simple_file",
@@ -222,7 +267,7 @@ port = 4000`,
 			expectString: `
 [client]
 host = example.com
-user = {{ .USER }}
+user = < USER >
 password = {{ .MYSQL_PASSWD }}
 port = 4000`,
 		},
@@ -276,136 +321,29 @@ config:
   access_key: <% S3_ACCESS_KEY %>
   secret_key: {{ .S3_SECRET_KEY }}`,
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			out, err := resolveAngleBrackets(tt.originalString)
-			if err != nil {
-				if err.Error() != tt.err.Error() {
-					t.Errorf("resolveAngleBrackets() returned an unexpected error: goot: %v", err)
-					t.Errorf("resolveAngleBrackets() returned an unexpected error: want: %v", tt.err)
-
-				}
-			} else {
-				if out != tt.expectString {
-					t.Errorf("resolveAngleBrackets() returned an unexpected string: got: %v, want: %s", out, tt.expectString)
-					fmt.Printf("Got length: %d, Want length: %d\n", len(out), len(tt.expectString))
-				}
-			}
-		})
-	}
-}
-
-func TestAddQuotesForCurlyBraces(t *testing.T) {
-	tests := []struct {
-		originalString string
-		expectString   string
-		err            error
-	}{
-		{
-			originalString: "test-ubuntu-20.04-linux",
-			expectString:   "test-ubuntu-20.04-linux",
-		},
-		{
-			originalString: "<% linux-test %>",
-			expectString:   `<% linux-test %>`,
-		},
-		{
-			originalString: "{{ .A }}-linux",
-			expectString:   `"{{ .A }}-linux"`,
-		},
-		{
-			originalString: "{{ .A }}-linux-<% ENV %>",
-			expectString:   `"{{ .A }}-linux-<% ENV %>"`,
-		},
-		{
-			originalString: `sn0rt-{{ .A }}-linux`,
-			expectString:   `"sn0rt-{{ .A }}-linux"`,
-		},
-		{
-			originalString: `sn0rt-{{ .A }}-{{ .B }}`,
-			expectString:   `"sn0rt-{{ .A }}-{{ .B }}"`,
-		},
-		{
-			originalString: `{{ .A }}-{{ .B }} {{ .C }}`,
-			expectString:   `"{{ .A }}-{{ .B }}" "{{ .C }}"`,
-		},
-		{
-			originalString: `sn0rt-{{ .A }}-{{ .B }} {{ .C }}`,
-			expectString:   `"sn0rt-{{ .A }}-{{ .B }}" "{{ .C }}"`,
-		},
-		{
-			originalString: `{{ .MYSQL_PASSWD }}`,
-			expectString:   `"{{ .MYSQL_PASSWD }}"`,
-		},
 		{
-			originalString: `{{ .<% ENV %>_MYSQL_PASSWD }}`,
-			expectString:   `"{{ .<% ENV %>_MYSQL_PASSWD }}"`,
+			name:           "literal_template_delimiters",
+			originalString: "release {{ .Release.Name }} = <PASSWD>",
+			expectString:   `release {{ "{{" }} .Release.Name }} = {{ .PASSWD }}`,
 		},
 		{
-			originalString: `password = {{ .MYSQL_PASSWD }}`,
-			expectString:   `password = "{{ .MYSQL_PASSWD }}"`,
+			name:           "names_that_are_no_identifiers",
+			originalString: "<db-user>:<db.password>@<1host>",
+			expectString:   `{{ index . "db-user" }}:{{ index . "db.password" }}@{{ index . "1host" }}`,
 		},
 		{
-			originalString: `password = {{ .MYSQL_PASSWD }}-<% ENV %>`,
-			expectString:   `password = "{{ .MYSQL_PASSWD }}-<% ENV %>"`,
-		},
-		{
-			originalString: `[client]
-host = example.com
-user = {{ .USER }}
-password = {{ .MYSQL_PASSWD }}
-port = 4000`,
-			expectString: `[client]
-host = example.com
-user = "{{ .USER }}"
-password = "{{ .MYSQL_PASSWD }}"
-port = 4000`,
-		},
-		{
-			originalString: `[client]
-host = example.com
-user = {{ .USER }}-password
-password = linux-{{ .MYSQL_PASSWD }}
-port = 4000`,
-			expectString: `[client]
-host = example.com
-user = "{{ .USER }}-password"
-password = "linux-{{ .MYSQL_PASSWD }}"
-port = 4000`,
-		},
-		{
-			originalString: `type: S3
-config:
-  endpoint: "https://s3.amazonaws.com"
-  access_key: {{ .S3_ACCESS_KEY }}
-  secret_key: {{ .S3_SECRET_KEY }}`,
-			expectString: `type: S3
-config:
-  endpoint: "https://s3.amazonaws.com"
-  access_key: "{{ .S3_ACCESS_KEY }}"
-  secret_key: "{{ .S3_SECRET_KEY }}"`,
-		},
-		{
-			originalString: `type: S3
-config:
-  endpoint: "https://s3.amazonaws.com"
-  access_key: {{ .S3_ACCESS_KEY }}-<% ENV %>
-  secret_key: {{ .S3_SECRET_KEY }}`,
-			expectString: `type: S3
-config:
-  endpoint: "https://s3.amazonaws.com"
-  access_key: "{{ .S3_ACCESS_KEY }}-<% ENV %>"
-  secret_key: "{{ .S3_SECRET_KEY }}"`,
+			name:           "literal_brace_before_placeholder",
+			originalString: "{<PASSWD>}",
+			expectString:   `{{ "{" }}{{ .PASSWD }}}`,
 		},
 	}
 
-	for idx, tt := range tests {
-		t.Run(fmt.Sprintf("test case %d", idx), func(t *testing.T) {
-			out := addQuotesCurlyBraces(tt.originalString)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := resolveAngleBracketsWith(tt.originalString, nil)
 			if out != tt.expectString {
-				t.Errorf("addQuotesCurlyBraces() returned an unexpected string: got: %v, want: %s", out, tt.expectString)
+				t.Errorf("resolveAngleBracketsWith() returned an unexpected string: got: %v, want: %s", out, tt.expectString)
+				fmt.Printf("Got length: %d, Want length: %d\n", len(out), len(tt.expectString))
 			}
 		})
 	}
@@ -453,6 +391,26 @@ kind: Secret
 apiVersion: v1
 kind: Secret`),
 		},
+		{
+			name: "should_keep_hash_of_path_reference",
+			input: []byte(`stringData:
+  user: <path:secret/data/foo#user> # trailing comment
+  password: <path:secret/data/foo#password#2>`),
+			want: []byte(`stringData:
+  user: <path:secret/data/foo#user> 
+  password: <path:secret/data/foo#password#2>`),
+		},
+		{
+			name: "should_strip_hash_outside_of_path_reference",
+			input: []byte(`stringData:
+  a: a#b
+  b: a #b
+  c: <path:secret/data/<% ENV %>/foo#user>#comment`),
+			want: []byte(`stringData:
+  a: a
+  b: a 
+  c: <path:secret/data/<% ENV %>/foo#user>`),
+		},
 	}
 
 	for _, tt := range tests {
@@ -464,3 +422,89 @@ kind: Secret`),
 		})
 	}
 }
+
+func TestResolveDefaultAndRequiredInSecret(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: defaults
+  annotations:
+    avp.kubernetes.io/path: "secret/data/<% REGION | default \"eu-west-1\" %>/app"
+type: Opaque
+stringData:
+  tenant: <% TENANT | required "tenant must be set" %>-<USER>
+  region: <% REGION | default "eu-west-1" %>
+`)
+	_, _, err := ConvertSecretContent(body, SecretStoreType, "test", esv1beta1.CreatePolicyOwner, true, nil)
+	var missing *MissingValuesError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expect a MissingValuesError, got: %v", err)
+	}
+	if len(missing.Occurrences) != 1 ||
+		missing.Occurrences[0].Err.Error() != fmt.Errorf(ErrCommonRequiredEnv, "TENANT", "tenant must be set").Error() {
+		t.Errorf("expect only TENANT to be reported as required, got: %v", err)
+	}
+
+	out, _, err := ConvertSecretContent(body, SecretStoreType, "test", esv1beta1.CreatePolicyOwner, true,
+		map[string]string{"TENANT": "a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expect := range []string{"key: eu-west-1/app", "region: eu-west-1", "a-{{ .USER }}"} {
+		if !strings.Contains(out, expect) {
+			t.Errorf("expect %q in output:\n%s", expect, out)
+		}
+	}
+}
+
+func TestEscapeTemplateText(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		render map[string]string
+		expect string
+	}{
+		{
+			name:   "static helm",
+			value:  "name: {{ .Release.Name }}-{{ include \"chart\" . }}",
+			expect: "name: {{ .Release.Name }}-{{ include \"chart\" . }}",
+		},
+		{
+			name:   "static braces",
+			value:  "{{{ a }}} {} }} {",
+			expect: "{{{ a }}} {} }} {",
+		},
+		{
+			name:   "prometheus alert",
+			value:  "summary: {{ $labels.instance }} down for <minutes>m\ndescription: {{ printf \"%.2f\" $value }}",
+			render: map[string]string{"minutes": "5"},
+			expect: "summary: {{ $labels.instance }} down for 5m\ndescription: {{ printf \"%.2f\" $value }}",
+		},
+		{
+			name:   "braces around placeholders",
+			value:  "{<a>}{{<b>}}",
+			render: map[string]string{"a": "1", "b": "2"},
+			expect: "{1}{{2}}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generated := escapeTemplateText(tt.value, false)
+			if tt.render != nil {
+				generated = resolveAngleBracketsWith(tt.value, nil)
+			}
+			tmpl, err := template.New(tt.name).Option("missingkey=error").Parse(generated)
+			if err != nil {
+				t.Fatalf("generated template %q does not parse: %v", generated, err)
+			}
+			var rendered strings.Builder
+			if err := tmpl.Execute(&rendered, tt.render); err != nil {
+				t.Fatalf("generated template %q does not render: %v", generated, err)
+			}
+			if rendered.String() != tt.expect {
+				t.Errorf("rendered %q, want %q", rendered.String(), tt.expect)
+			}
+		})
+	}
+}
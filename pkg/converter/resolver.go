@@ -0,0 +1,26 @@
+package converter
+
+import "os"
+
+// Resolver looks up the values of <% VAR %> placeholders. A conversion uses a
+// single resolver so concurrent conversions never share variables.
+type Resolver interface {
+	Lookup(name string) (string, bool)
+}
+
+// MapResolver resolves placeholders from a fixed set of variables, such as
+// the envVars of an HTTP request.
+type MapResolver map[string]string
+
+func (m MapResolver) Lookup(name string) (string, bool) {
+	value, ok := m[name]
+	return value, ok
+}
+
+// EnvResolver resolves placeholders from the process environment, it only
+// reads the environment and is what the CLI uses.
+type EnvResolver struct{}
+
+func (EnvResolver) Lookup(name string) (string, bool) {
+	return os.LookupEnv(name)
+}
@@ -0,0 +1,68 @@
+package converter
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+)
+
+// testResolver returns the resolver of a test case, nil disables resolving.
+func testResolver(enable bool, envs map[string]string) Resolver {
+	if !enable {
+		return nil
+	}
+	return MapResolver(envs)
+}
+
+func TestEnvResolver(t *testing.T) {
+	t.Setenv("SECRET2ES_RESOLVER_TEST", "from-env")
+	out, err := resolved("<% SECRET2ES_RESOLVER_TEST %>-linux", EnvResolver{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "from-env-linux" {
+		t.Errorf("resolved() returned an unexpected string: got: %s, want: %s", out, "from-env-linux")
+	}
+}
+
+func TestConvertSecretContentIsolatesEnvVars(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: isolated
+  annotations:
+    avp.kubernetes.io/path: "secret/data/<% ENV %>"
+type: Opaque
+stringData:
+  user: <USER>
+`)
+
+	var wg sync.WaitGroup
+	for _, env := range []string{"dev", "stage", "prod", "test"} {
+		wg.Add(1)
+		go func(env string) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				out, _, err := ConvertSecretContent(body, SecretStoreType, "test", esv1beta1.CreatePolicyOwner,
+					true, map[string]string{"ENV": env})
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				if !strings.Contains(out, "key: "+env+"\n") {
+					t.Errorf("env %s leaked into another conversion:\n%s", env, out)
+					return
+				}
+			}
+		}(env)
+	}
+	wg.Wait()
+
+	// a later request must not see the variables of the earlier ones
+	_, _, err := ConvertSecretContent(body, SecretStoreType, "test", esv1beta1.CreatePolicyOwner, true, nil)
+	if err == nil {
+		t.Errorf("expect ENV to be unset for a request without envVars")
+	}
+}
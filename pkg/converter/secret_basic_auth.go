@@ -7,7 +7,7 @@ import (
 )
 
 func generateEsByBasicAuthSecret(inputSecret *internalSecret, storeType, storeName string,
-	creationPolicy esv1beta1.ExternalSecretCreationPolicy, resolve bool) (*esv1beta1.ExternalSecret, error) {
+	creationPolicy esv1beta1.ExternalSecretCreationPolicy, resolver Resolver) (*esv1beta1.ExternalSecret, error) {
 	if len(inputSecret.Data) != 0 {
 		return nil, fmt.Errorf(ErrBasicAuthNotAllowDataField, inputSecret.Name)
 	}
@@ -18,7 +18,7 @@ func generateEsByBasicAuthSecret(inputSecret *internalSecret, storeType, storeNa
 		return nil, fmt.Errorf(ErrBasicAuthWithEmptyPassword, inputSecret.Name)
 	}
 
-	output, err := generateEsByOpaqueSecret(inputSecret, storeType, storeName, creationPolicy, resolve)
+	output, err := generateEsByOpaqueSecret(inputSecret, storeType, storeName, creationPolicy, resolver)
 	if err != nil {
 		return nil, err
 	}
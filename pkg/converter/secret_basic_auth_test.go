@@ -8,7 +8,6 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"os"
 	"testing"
 )
 
@@ -96,8 +95,8 @@ func TestGenerateBasicAuthSecret(t *testing.T) {
 							MergePolicy: esv1beta1.MergePolicyReplace,
 							Data: map[string]string{
 								"host":     "localhost.local",
-								"username": `"{{ .USER_ACCESS_KEY }}"`,
-								"password": `"sn0rt_{{ .USER_SECRET_KEY }}"`,
+								"username": `{{ .USER_ACCESS_KEY }}`,
+								"password": `sn0rt_{{ .USER_SECRET_KEY }}`,
 							},
 						},
 					},
@@ -141,10 +140,7 @@ func TestGenerateBasicAuthSecret(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for k, v := range tt.envs {
-				_ = os.Setenv(k, v)
-			}
-			externalSecret, err := convertSecret2ExtSecret(tt.inputSecret, tt.store.Kind, tt.store.Name, esv1beta1.CreatePolicyOrphan, true)
+			externalSecret, err := convertSecret2ExtSecret(tt.inputSecret, tt.store.Kind, tt.store.Name, esv1beta1.CreatePolicyOrphan, MapResolver(tt.envs))
 			if err != nil {
 				if tt.err == nil {
 					t.Errorf("unexpected error: %v", err)
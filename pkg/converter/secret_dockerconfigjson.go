@@ -6,6 +6,7 @@ import (
 	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
 	"strings"
 )
 
@@ -18,7 +19,7 @@ type Auths struct {
 }
 
 func generateEsByDockerConfigJSON(inputSecret *internalSecret, storeType, storeName string,
-	creationPolicy esv1beta1.ExternalSecretCreationPolicy, resolve bool) (*esv1beta1.ExternalSecret, error) {
+	creationPolicy esv1beta1.ExternalSecretCreationPolicy, resolver Resolver) (*esv1beta1.ExternalSecret, error) {
 	if len(inputSecret.Data) != 0 {
 		return nil, fmt.Errorf(ErrDockerConfigJsonAcceptOnlyDataFields, inputSecret.Name)
 	}
@@ -37,41 +38,39 @@ func generateEsByDockerConfigJSON(inputSecret *internalSecret, storeType, storeN
 		return nil, err
 	}
 
-	// prepare the ref of sensitive data
-	var externalSecretData []esv1beta1.ExternalSecretData
-	for _, loginInfo := range authFileContent.Auths {
-		propertyFromSecretData := captureFromFile.FindAllSubmatch([]byte(loginInfo.Auth), -1)
-		if len(propertyFromSecretData) == 0 {
-			continue
-		}
-		for _, s := range propertyFromSecretData {
-			output := strings.TrimSpace(string(s[1]))
-			// if secret key not found in externalSecretData then append to slice
-			if !contains(externalSecretData, output) {
-				externalSecretData = append(externalSecretData, esv1beta1.ExternalSecretData{
-					SecretKey: output,
-					RemoteRef: esv1beta1.ExternalSecretDataRemoteRef{
-						ConversionStrategy: esv1beta1.ExternalSecretConversionDefault,
-						DecodingStrategy:   esv1beta1.ExternalSecretDecodeNone,
-						MetadataPolicy:     esv1beta1.ExternalSecretMetadataPolicyNone,
-						Key:                vaultSecretKey,
-						Property:           output,
-					},
-				})
+	// prepare the ref of sensitive data, registries in order so spec.data is stable
+	refs := newSecretReferences(vaultSecretKey, esv1beta1.ExternalSecretDecodeNone)
+	for _, registry := range sortedAuthKeys(authFileContent.Auths) {
+		loginInfo := authFileContent.Auths[registry]
+		for _, p := range findPlaceholders(loginInfo.Auth) {
+			if err := refs.add(p.name); err != nil {
+				return nil, inputSecret.sourceError(sourceFieldStringData, ".dockerconfigjson", err)
 			}
 		}
 	}
+	externalSecretData := refs.data()
 
 	// render template
 	templateData := make(map[string]string)
 	var dockerloginfo = Auths{
 		Auths: make(map[string]Auth),
 	}
-	for key, value := range authFileContent.Auths {
-		var singleLoginfo = Auth{}
-		var t, _ = resolveAngleBrackets(value.Auth)
-		singleLoginfo.Auth = t
-		dockerloginfo.Auths[key] = singleLoginfo
+	for _, key := range sortedAuthKeys(authFileContent.Auths) {
+		value := authFileContent.Auths[key]
+		// pin a template error to the auth in the file content
+		base := strings.Index(inputSecret.StringData[".dockerconfigjson"], value.Auth)
+		if base == -1 {
+			base = 0
+		}
+		segments := templateSegments(value.Auth, refs.alias)
+		for i := range segments {
+			segments[i].offset += base
+		}
+		auth, err := inputSecret.checkTemplate(sourceFieldStringData, ".dockerconfigjson", segments)
+		if err != nil {
+			return nil, err
+		}
+		dockerloginfo.Auths[key] = Auth{Auth: auth}
 	}
 	var out, _ = json.MarshalIndent(&dockerloginfo, "", "  ")
 	templateData[".dockerconfigjson"] = string(out)
@@ -117,3 +116,12 @@ func serializeDockerConfigJSON(dockerConfigJson []byte) (*Auths, error) {
 	}
 	return &dockerConfigJSON, nil
 }
+
+func sortedAuthKeys(auths map[string]Auth) []string {
+	keys := make([]string, 0, len(auths))
+	for key := range auths {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -3,26 +3,19 @@ package converter
 import (
 	"encoding/base64"
 	"fmt"
-	"regexp"
-	"strings"
 
 	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-var (
-	captureFromFileNew = regexp.MustCompile(`<([^<>]+)>|<(<[^>]*>.*?)>`)
-	captureFromFile    = regexp.MustCompile(`<([^<>]+)>`)
-)
-
 const (
 	opaqueDataType = iota
 	opaqueStringDataType
 )
 
 func generateEsByOpaqueSecret(inputSecret *internalSecret, storeType, storeName string,
-	creationPolicy esv1beta1.ExternalSecretCreationPolicy, isResolve bool) (*esv1beta1.ExternalSecret, error) {
+	creationPolicy esv1beta1.ExternalSecretCreationPolicy, resolver Resolver) (*esv1beta1.ExternalSecret, error) {
 	var currentSecretOpaqueSubType int
 	if len(inputSecret.Data) != 0 {
 		currentSecretOpaqueSubType = opaqueDataType
@@ -42,123 +35,114 @@ func generateEsByOpaqueSecret(inputSecret *internalSecret, storeType, storeName
 
 	switch currentSecretOpaqueSubType {
 	case opaqueDataType:
+		refs := newSecretReferences(vaultSecretKey, esv1beta1.ExternalSecretDecodeBase64)
+		pending := make(map[string]string)
+
 		// 1. resolve the <% KEY %> from ENV
-		if isResolve {
-			if err := resolveSecret(inputSecret); err != nil {
+		if resolver != nil {
+			if err := resolveSecret(inputSecret, resolver); err != nil {
 				return nil, err
 			}
 		}
 
 		// static value add to template directly
 		// dynamic value add to externalSecretData
-		for key, value := range inputSecret.Data {
-			propertyFromSecretData := captureFromFileNew.FindAllStringSubmatch(value, -1)
-			if len(propertyFromSecretData) == 0 {
+		for _, key := range inputSecret.orderedKeys(sourceFieldData, inputSecret.Data) {
+			value := inputSecret.Data[key]
+			ranges := placeholderRanges(value)
+			if len(ranges) == 0 {
+				var err error
 				if IsBase64(value) {
-					templateData[key] = fmt.Sprintf(`{{ "%s" | b64dec }}`, value)
+					templateData[key], err = inputSecret.checkTemplate(sourceFieldData, key,
+						[]templateSegment{{text: fmt.Sprintf(`{{ "%s" | b64dec }}`, value)}})
+					inputSecret.explainRule(sourceFieldData, key, ruleDataStaticBase64)
 				} else {
-					templateData[key] = value
+					templateData[key], err = inputSecret.renderTemplate(sourceFieldData, key, value, nil)
+					inputSecret.explainRule(sourceFieldData, key, ruleDataStatic)
+				}
+				if err != nil {
+					return nil, err
 				}
 				continue
 			}
 
-			if len(propertyFromSecretData) != 1 {
-				return nil, fmt.Errorf(ErrCommonNotSupportMultipleValue, inputSecret.Name)
+			if len(ranges) != 1 {
+				return nil, inputSecret.sourceErrorAt(sourceFieldData, key, ranges[1][0],
+					fmt.Errorf(ErrCommonNotSupportMultipleValue, inputSecret.Name))
 			}
 
-			if strings.HasPrefix(propertyFromSecretData[0][0], "<%") &&
-				strings.HasSuffix(propertyFromSecretData[0][0], "%>") {
-				templateData[key] = propertyFromSecretData[0][0]
+			placeholders := findPlaceholders(value)
+			if len(placeholders) == 0 {
+				text, err := inputSecret.renderTemplate(sourceFieldData, key, value, nil)
+				if err != nil {
+					return nil, err
+				}
+				templateData[key] = text
+				inputSecret.explainRule(sourceFieldData, key, ruleDataEnv)
 				continue
 			}
 
-			var propertyName string
-			if propertyFromSecretData[0][1] != "" {
-				propertyName = propertyFromSecretData[0][1]
-			} else {
-				propertyName = propertyFromSecretData[0][2]
-			}
-
-			if !contains(externalSecretData, propertyName) {
-				externalSecretData = append(externalSecretData, esv1beta1.ExternalSecretData{
-					SecretKey: propertyName,
-					RemoteRef: esv1beta1.ExternalSecretDataRemoteRef{
-						ConversionStrategy: esv1beta1.ExternalSecretConversionDefault,
-						DecodingStrategy:   esv1beta1.ExternalSecretDecodeBase64,
-						MetadataPolicy:     esv1beta1.ExternalSecretMetadataPolicyNone,
-						Key:                vaultSecretKey,
-						Property:           propertyName,
-					},
-				})
+			if err := refs.add(placeholders[0].name); err != nil {
+				return nil, inputSecret.sourceError(sourceFieldData, key, err)
 			}
+			pending[key] = value
+			inputSecret.explainRule(sourceFieldData, key, ruleDataPlaceholder)
+		}
 
-			newFileContentWithoutQuote, err := resolveAngleBrackets(value)
+		// 2. render the templates once the aliases of all the references are known
+		for _, key := range inputSecret.orderedKeys(sourceFieldData, pending) {
+			text, err := inputSecret.renderTemplate(sourceFieldData, key, pending[key], refs.alias)
 			if err != nil {
 				return nil, err
 			}
-			var newFileContent = addQuotesCurlyBraces(newFileContentWithoutQuote)
-			templateData[key] = newFileContent
+			templateData[key] = text
 		}
+		externalSecretData = refs.data()
 	case opaqueStringDataType:
+		refs := newSecretReferences(vaultSecretKey, esv1beta1.ExternalSecretDecodeNone)
+		pending := make(map[string]string)
+
 		// 1. resolve the <% KEY %> from ENV
-		if isResolve {
-			if err := resolveSecret(inputSecret); err != nil {
+		if resolver != nil {
+			if err := resolveSecret(inputSecret, resolver); err != nil {
 				return nil, err
 			}
 		}
 
 		// 2. process the secret key from file content
-		for fileName, fileContent := range inputSecret.StringData {
-			propertyFromSecretData := captureFromFileNew.FindAllStringSubmatch(fileContent, -1)
+		for _, fileName := range inputSecret.orderedKeys(sourceFieldStringData, inputSecret.StringData) {
+			fileContent := inputSecret.StringData[fileName]
+			placeholders := findPlaceholders(fileContent)
 			// simple case, no need to resolve
-			if len(propertyFromSecretData) == 0 {
-				templateData[fileName] = fileContent
+			if len(placeholders) == 0 {
+				text, err := inputSecret.renderTemplate(sourceFieldStringData, fileName, fileContent, nil)
+				if err != nil {
+					return nil, err
+				}
+				templateData[fileName] = text
+				inputSecret.explainRule(sourceFieldStringData, fileName, ruleStringDataStatic)
 				continue
 			}
 
-			// prepare the resolved file content
-			resolvedFileContent := fileContent
-
 			// resolve the secret key from file content
-			for idx, _ := range propertyFromSecretData {
-				if strings.HasPrefix(propertyFromSecretData[idx][0], "<%") &&
-					strings.HasSuffix(propertyFromSecretData[idx][0], "%>") {
-					continue
-				}
-
-				var propertyName string
-				if propertyFromSecretData[idx][1] != "" {
-					propertyName = propertyFromSecretData[idx][1]
-				} else {
-					propertyName = propertyFromSecretData[idx][2]
-				}
-
-				// if secret key not found in externalSecretData then append to slice
-				if !contains(externalSecretData, propertyName) {
-					externalSecretData = append(externalSecretData, esv1beta1.ExternalSecretData{
-						SecretKey: strings.TrimSpace(propertyName),
-						RemoteRef: esv1beta1.ExternalSecretDataRemoteRef{
-							ConversionStrategy: esv1beta1.ExternalSecretConversionDefault,
-							DecodingStrategy:   esv1beta1.ExternalSecretDecodeNone,
-							MetadataPolicy:     esv1beta1.ExternalSecretMetadataPolicyNone,
-							Key:                vaultSecretKey,
-							Property:           strings.TrimSpace(propertyName),
-						},
-					})
+			for _, p := range placeholders {
+				if err := refs.add(p.name); err != nil {
+					return nil, inputSecret.sourceError(sourceFieldStringData, fileName, err)
 				}
 			}
+			pending[fileName] = fileContent
+			inputSecret.explainRule(sourceFieldStringData, fileName, ruleStringDataPlaceholders)
+		}
 
-			newFileContentWithoutQuote, err := resolveAngleBrackets(resolvedFileContent)
+		// 3. render the templates once the aliases of all the references are known
+		for _, fileName := range inputSecret.orderedKeys(sourceFieldStringData, pending) {
+			text, err := inputSecret.renderTemplate(sourceFieldStringData, fileName, pending[fileName], refs.alias)
 			if err != nil {
 				return nil, err
 			}
-			if !strings.Contains(newFileContentWithoutQuote, "\n") {
-				var newFileContent = addQuotesCurlyBraces(newFileContentWithoutQuote)
-				templateData[fileName] = newFileContent
-			} else {
-				templateData[fileName] = newFileContentWithoutQuote
-			}
+			templateData[fileName] = text
 		}
+		externalSecretData = refs.data()
 	}
 
 	if len(externalSecretData) == 0 {
@@ -199,39 +183,31 @@ func generateEsByOpaqueSecret(inputSecret *internalSecret, storeType, storeName
 	}, nil
 }
 
-func resolveSecret(inputSecret *internalSecret) (err error) {
-	for fileName, fileContent := range inputSecret.Data {
-		propertyFromSecretData := captureFromFile.FindAllStringSubmatch(fileContent, -1)
-		// simple case, no need to resolve
-
-		// resolve the secret key from file content
-		for idx, _ := range propertyFromSecretData {
-			if strings.HasPrefix(propertyFromSecretData[idx][0], "<%") &&
-				strings.HasSuffix(propertyFromSecretData[idx][0], "%>") {
-				inputSecret.Data[fileName], err = resolved(fileContent)
-				if err != nil {
-					return err
-				}
-				continue
-			}
+func resolveSecret(inputSecret *internalSecret, resolver Resolver) error {
+	for _, fileName := range inputSecret.orderedKeys(sourceFieldData, inputSecret.Data) {
+		fileContent := inputSecret.Data[fileName]
+		// process if match <% ... %>
+		if !resolvedValueFromEnv.MatchString(fileContent) {
+			continue
 		}
+		resolvedContent, err := resolved(fileContent, resolver)
+		if err != nil {
+			return inputSecret.sourceError(sourceFieldData, fileName, err)
+		}
+		inputSecret.Data[fileName] = resolvedContent
 	}
 
-	for fileName, fileContent := range inputSecret.StringData {
-		propertyFromSecretData := captureFromFile.FindAllStringSubmatch(fileContent, -1)
-
-		// resolve the secret key from file content
-		for idx, _ := range propertyFromSecretData {
-			// process if match <% ... %>
-			if strings.HasPrefix(propertyFromSecretData[idx][0], "<%") &&
-				strings.HasSuffix(propertyFromSecretData[idx][0], "%>") {
-				inputSecret.StringData[fileName], err = resolved(fileContent)
-				if err != nil {
-					return err
-				}
-				continue
-			}
+	for _, fileName := range inputSecret.orderedKeys(sourceFieldStringData, inputSecret.StringData) {
+		fileContent := inputSecret.StringData[fileName]
+		// process if match <% ... %>
+		if !resolvedValueFromEnv.MatchString(fileContent) {
+			continue
+		}
+		resolvedContent, err := resolved(fileContent, resolver)
+		if err != nil {
+			return inputSecret.sourceError(sourceFieldStringData, fileName, err)
 		}
+		inputSecret.StringData[fileName] = resolvedContent
 	}
 
 	return nil
@@ -241,12 +217,3 @@ func IsBase64(s string) bool {
 	_, err := base64.StdEncoding.DecodeString(s)
 	return err == nil
 }
-
-func contains(data []esv1beta1.ExternalSecretData, output string) bool {
-	for _, d := range data {
-		if d.SecretKey == output {
-			return true
-		}
-	}
-	return false
-}
@@ -7,7 +7,6 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"os"
 	"testing"
 )
 
@@ -74,7 +73,7 @@ func TestGenerateStringDataOpaqueSecret(t *testing.T) {
 							},
 							MergePolicy: esv1beta1.MergePolicyReplace,
 							Data: map[string]string{
-								"dist": `"{{ .dist-name-of-linux }}"`,
+								"dist": `{{ index . "dist-name-of-linux" }}`,
 								"env1": "<% ENV %>",
 							},
 						},
@@ -151,8 +150,8 @@ func TestGenerateStringDataOpaqueSecret(t *testing.T) {
 							},
 							MergePolicy: esv1beta1.MergePolicyReplace,
 							Data: map[string]string{
-								"dist": `"{{ .dist-name-of-linux }}"`,
-								"env1": `"<% ENV %>-{{ .dist-name-of-linux }}"`,
+								"dist": `{{ index . "dist-name-of-linux" }}`,
+								"env1": `<% ENV %>-{{ index . "dist-name-of-linux" }}`,
 							},
 						},
 					},
@@ -229,9 +228,9 @@ func TestGenerateStringDataOpaqueSecret(t *testing.T) {
 							},
 							MergePolicy: esv1beta1.MergePolicyReplace,
 							Data: map[string]string{
-								"env0": `"{{ .VAULT0 }}"`,
-								"env1": `"{{ .<% ENV1 %>_VAULT1 }}"`,
-								"env2": `"{{ .<% ENV2 %>_VAULT2 }}"`,
+								"env0": `{{ .VAULT0 }}`,
+								"env1": `{{ index . "<% ENV1 %>_VAULT1" }}`,
+								"env2": `{{ index . "<% ENV2 %>_VAULT2" }}`,
 							},
 						},
 					},
@@ -327,16 +326,6 @@ port = 4000`,
 						Kind: "ClusterSecretStore",
 					},
 					Data: []esv1beta1.ExternalSecretData{
-						{
-							SecretKey: "USER",
-							RemoteRef: esv1beta1.ExternalSecretDataRemoteRef{
-								Key:                "ubuntu-22.04-foo",
-								MetadataPolicy:     "None",
-								Property:           "USER",
-								ConversionStrategy: "Default",
-								DecodingStrategy:   "None",
-							},
-						},
 						{
 							SecretKey: "MYSQL_PASSWD",
 							RemoteRef: esv1beta1.ExternalSecretDataRemoteRef{
@@ -363,7 +352,7 @@ port = 4000`,
 							Data: map[string]string{
 								"mylogin.conf": `[client]
 host = example.com
-user = {{ .USER }}
+user = < USER >
 password = {{ .MYSQL_PASSWD }}
 port = 4000`,
 							},
@@ -424,16 +413,6 @@ port = 4000`,
 						Kind: "ClusterSecretStore",
 					},
 					Data: []esv1beta1.ExternalSecretData{
-						{
-							SecretKey: "USER_ACCESS_KEY",
-							RemoteRef: esv1beta1.ExternalSecretDataRemoteRef{
-								Key:                "ubuntu-22.04-foo",
-								MetadataPolicy:     "None",
-								Property:           "USER_ACCESS_KEY",
-								ConversionStrategy: "Default",
-								DecodingStrategy:   "None",
-							},
-						},
 						{
 							SecretKey: "USER_SECRET_KEY",
 							RemoteRef: esv1beta1.ExternalSecretDataRemoteRef{
@@ -458,9 +437,9 @@ port = 4000`,
 							},
 							MergePolicy: esv1beta1.MergePolicyReplace,
 							Data: map[string]string{
-								"sn0rt.github.io.default.access_key": `"{{ .USER_ACCESS_KEY }}"`,
-								"sn0rt.github.io.default.secret_key": `"{{ .USER_SECRET_KEY }}"`,
-								"sn0rt.github.io.default.cmt":        `"sn0rt-{{ .USER_SECRET_KEY }}"`,
+								"sn0rt.github.io.default.access_key": "< USER_ACCESS_KEY >",
+								"sn0rt.github.io.default.secret_key": `{{ .USER_SECRET_KEY }}`,
+								"sn0rt.github.io.default.cmt":        `sn0rt-{{ .USER_SECRET_KEY }}`,
 								"sn0rt.github.io.default.key":        "key",
 							},
 						},
@@ -544,7 +523,7 @@ port = 4000`,
 							},
 							MergePolicy: esv1beta1.MergePolicyReplace,
 							Data: map[string]string{
-								"sn0rt.github.io.default.access_key": `"{{ .USER_ACCESS_KEY }}"`,
+								"sn0rt.github.io.default.access_key": `{{ .USER_ACCESS_KEY }}`,
 								"sn0rt.github.io.default.key":        "key",
 								"sn0rt.github.io.default.secret_key": "secret_key",
 							},
@@ -581,8 +560,9 @@ port = 4000`,
 				Name: "tenant-b",
 			},
 			envs: map[string]string{
-				"DIST": "ubuntu",
-				"VER":  "22.04",
+				"DIST":            "ubuntu",
+				"VER":             "22.04",
+				"USER_SECRET_KEY": "secret_key",
 			},
 			err: fmt.Errorf(ErrCommonNotNeedRefData, "multiple_stringData_should_empty_ref"),
 		},
@@ -690,10 +670,7 @@ config:
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for k, v := range tt.envs {
-				_ = os.Setenv(k, v)
-			}
-			externalSecret, err := convertSecret2ExtSecret(tt.inputSecret, tt.store.Kind, tt.store.Name, esv1beta1.CreatePolicyOrphan, tt.enableResolve)
+			externalSecret, err := convertSecret2ExtSecret(tt.inputSecret, tt.store.Kind, tt.store.Name, esv1beta1.CreatePolicyOrphan, testResolver(tt.enableResolve, tt.envs))
 			if err != nil {
 				if tt.err.Error() != err.Error() {
 					t.Errorf("Err Mismatch (+goot: %s)\n", err)
@@ -798,7 +775,7 @@ func TestGenerateDataOpaqueSecret(t *testing.T) {
 							},
 							MergePolicy: esv1beta1.MergePolicyReplace,
 							Data: map[string]string{
-								"dist": `"{{ .dist-name-of-linux }}"`,
+								"dist": `{{ index . "dist-name-of-linux" }}`,
 							},
 						},
 					},
@@ -873,7 +850,7 @@ func TestGenerateDataOpaqueSecret(t *testing.T) {
 							Type:        corev1.SecretTypeOpaque,
 							Metadata:    esv1beta1.ExternalSecretTemplateMetadata{Labels: map[string]string{"app": "test"}},
 							MergePolicy: esv1beta1.MergePolicyReplace,
-							Data:        map[string]string{"dist": `"{{ .dist-name-of-linux }}"`},
+							Data:        map[string]string{"dist": `{{ index . "dist-name-of-linux" }}`},
 						},
 					},
 					SecretStoreRef: esv1beta1.SecretStoreRef{
@@ -954,9 +931,9 @@ func TestGenerateDataOpaqueSecret(t *testing.T) {
 							},
 							MergePolicy: esv1beta1.MergePolicyReplace,
 							Data: map[string]string{
-								"dist":   `"{{ .dist-name-of-linux }}"`,
-								"passwd": `"{{ .github-passwd }}"`,
-								"user":   `"{{ .github-username }}"`,
+								"dist":   `{{ index . "dist-name-of-linux" }}`,
+								"passwd": `{{ index . "github-passwd" }}`,
+								"user":   `{{ index . "github-username" }}`,
 							},
 						},
 					},
@@ -1053,9 +1030,9 @@ func TestGenerateDataOpaqueSecret(t *testing.T) {
 							},
 							MergePolicy: esv1beta1.MergePolicyReplace,
 							Data: map[string]string{
-								"env0": `"{{ .VAULT0 }}"`,
-								"env1": `"{{ .<% ENV1 %>_VAULT1 }}"`,
-								"env2": `"{{ .<% ENV2 %>_VAULT2 }}"`,
+								"env0": `{{ .VAULT0 }}`,
+								"env1": `{{ index . "<% ENV1 %>_VAULT1" }}`,
+								"env2": `{{ index . "<% ENV2 %>_VAULT2" }}`,
 							},
 						},
 					},
@@ -1213,7 +1190,7 @@ func TestGenerateDataOpaqueSecret(t *testing.T) {
 							Data: map[string]string{
 								"data1": "data1",
 								"data2": "ubuntu",
-								"data3": `"{{ .FROM_VAULT_DATA3 }}"`,
+								"data3": `{{ .FROM_VAULT_DATA3 }}`,
 							},
 						},
 					},
@@ -1261,16 +1238,13 @@ func TestGenerateDataOpaqueSecret(t *testing.T) {
 				Name: "test",
 				Kind: "ClusterSecretStore",
 			},
-			err: fmt.Errorf(ErrCommonNotSupportMultipleValue, "mix_example1"),
+			err: &SourceError{Secret: "mix_example1", Key: "key1", Err: fmt.Errorf(ErrCommonNotSupportMultipleValue, "mix_example1")},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for k, v := range tt.envs {
-				_ = os.Setenv(k, v)
-			}
-			externalSecret, err := convertSecret2ExtSecret(tt.inputSecret, tt.store.Kind, tt.store.Name, esv1beta1.CreatePolicyOrphan, tt.enableResolve)
+			externalSecret, err := convertSecret2ExtSecret(tt.inputSecret, tt.store.Kind, tt.store.Name, esv1beta1.CreatePolicyOrphan, testResolver(tt.enableResolve, tt.envs))
 			if err != nil {
 				if tt.err.Error() != err.Error() {
 					t.Errorf("Err Mismatch (+goot: %s)\n", err)
@@ -1350,7 +1324,7 @@ func TestGenerateCreatePolicy(t *testing.T) {
 							},
 							MergePolicy: esv1beta1.MergePolicyReplace,
 							Data: map[string]string{
-								"dist": `"{{ .dist-name-of-linux }}"`,
+								"dist": `{{ index . "dist-name-of-linux" }}`,
 							},
 						},
 					},
@@ -1377,10 +1351,7 @@ func TestGenerateCreatePolicy(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for k, v := range tt.envs {
-				_ = os.Setenv(k, v)
-			}
-			externalSecret, err := convertSecret2ExtSecret(tt.inputSecret, tt.store.Kind, tt.store.Name, esv1beta1.CreatePolicyOwner, true)
+			externalSecret, err := convertSecret2ExtSecret(tt.inputSecret, tt.store.Kind, tt.store.Name, esv1beta1.CreatePolicyOwner, MapResolver(tt.envs))
 			if err != nil {
 				if tt.err.Error() != err.Error() {
 					t.Errorf("Err Mismatch (+goot: %s)\n", err)
@@ -1461,7 +1432,7 @@ func TestSkipResolveValue(t *testing.T) {
 							},
 							MergePolicy: esv1beta1.MergePolicyReplace,
 							Data: map[string]string{
-								"dist": `"{{ .dist-name-of-linux }}"`,
+								"dist": `{{ index . "dist-name-of-linux" }}`,
 								"env1": "<% ENV1 %>",
 							},
 						},
@@ -1539,9 +1510,9 @@ func TestSkipResolveValue(t *testing.T) {
 							},
 							MergePolicy: esv1beta1.MergePolicyReplace,
 							Data: map[string]string{
-								"dist": `"{{ .dist-name-of-linux }}"`,
+								"dist": `{{ index . "dist-name-of-linux" }}`,
 								"env1": "<% ENV1 %>",
-								"env2": `"<% ENV1 %>-{{ .dist-name-of-linux }}"`,
+								"env2": `<% ENV1 %>-{{ index . "dist-name-of-linux" }}`,
 							},
 						},
 					},
@@ -1589,16 +1560,13 @@ func TestSkipResolveValue(t *testing.T) {
 				Name: "test",
 				Kind: "ClusterSecretStore",
 			},
-			err: fmt.Errorf(ErrCommonNotSupportMultipleValue, "mix_example2"),
+			err: &SourceError{Secret: "mix_example2", Key: "key", Err: fmt.Errorf(ErrCommonNotSupportMultipleValue, "mix_example2")},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			for k, v := range tt.envs {
-				_ = os.Setenv(k, v)
-			}
-			externalSecret, err := convertSecret2ExtSecret(tt.inputSecret, tt.store.Kind, tt.store.Name, esv1beta1.CreatePolicyOwner, false)
+			externalSecret, err := convertSecret2ExtSecret(tt.inputSecret, tt.store.Kind, tt.store.Name, esv1beta1.CreatePolicyOwner, nil)
 			if err != nil {
 				if tt.err.Error() != err.Error() {
 					t.Errorf("Err Mismatch (+goot: %s)\n", err)
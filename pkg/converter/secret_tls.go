@@ -6,10 +6,10 @@ import (
 )
 
 func generateEsByTLS(inputSecret *internalSecret, storeType, storeName string,
-	creationPolicy esv1beta1.ExternalSecretCreationPolicy, resolve bool) (*esv1beta1.ExternalSecret, error) {
+	creationPolicy esv1beta1.ExternalSecretCreationPolicy, resolver Resolver) (*esv1beta1.ExternalSecret, error) {
 
 	// prepare the ref of sensitive data
-	output, err := generateEsByOpaqueSecret(inputSecret, storeType, storeName, creationPolicy, resolve)
+	output, err := generateEsByOpaqueSecret(inputSecret, storeType, storeName, creationPolicy, resolver)
 	if err != nil {
 		return nil, err
 	}
@@ -1,24 +1,48 @@
 package converter
 
 import (
+	"errors"
 	"fmt"
 	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"os"
-	"sigs.k8s.io/yaml"
 	"strings"
 )
 
-// ConvertSecret converts a AVP Secret to an ExternalSecret for CLI
+// ConvertOptions are the optional behaviours of a conversion, the zero value
+// is the default output.
+type ConvertOptions struct {
+	// ExtractPaths replaces the spec.data of a secret mirroring a whole vault
+	// path by a dataFrom.extract, which reads every property of the path.
+	ExtractPaths bool
+	// TemplateFromSize moves the multi-line template values larger than this
+	// many bytes to a ConfigMap referenced by templateFrom, 0 keeps them inline.
+	TemplateFromSize int
+	// Format is one of OutputFormats, YAML when empty.
+	Format string
+	// Trace annotates every generated object with its source file, document
+	// index and hash, and ToolVersion when set.
+	Trace       bool
+	ToolVersion string
+	// Strict fails on the angle brackets that look like a placeholder but
+	// are not one, < user >, <db password> or <PATH:...>, instead of leaving
+	// them as literal text.
+	Strict bool
+	// Cache takes the unchanged documents from and stores the converted ones
+	// to disk when set.
+	Cache *ConvertCache
+}
+
+// ConvertSecret converts a AVP Secret to an ExternalSecret for CLI, the
+// <% VAR %> placeholders are resolved from the environment when resolve is set.
 func ConvertSecret(inputFile, storeType, storeName string, creationPolicy esv1beta1.ExternalSecretCreationPolicy, resolve bool) error {
-	bytes, err := os.ReadFile(inputFile)
-	if err != nil {
-		return fmt.Errorf("error reading inputSecret file: %w", err)
+	var resolver Resolver
+	if resolve {
+		resolver = EnvResolver{}
 	}
-
-	output, warn, err := ConvertSecretContent(bytes, storeType, storeName, creationPolicy, resolve, nil)
+	output, warn, err := ConvertSecretFile(inputFile, storeType, storeName, creationPolicy, resolver, ConvertOptions{})
 	if err != nil {
-		return fmt.Errorf("error converting secret: %w", err)
+		return err
 	}
 	if warn != "" {
 		_, _ = fmt.Fprintf(os.Stderr, "warn: %s", warn)
@@ -30,112 +54,219 @@ func ConvertSecret(inputFile, storeType, storeName string, creationPolicy esv1be
 	return nil
 }
 
+// ConvertSecretFile converts the AVP Secrets of inputFile and returns the
+// ExternalSecrets and warnings instead of printing them.
+func ConvertSecretFile(inputFile, storeType, storeName string, creationPolicy esv1beta1.ExternalSecretCreationPolicy, resolver Resolver, opts ConvertOptions) (string, string, error) {
+	bytes, err := os.ReadFile(inputFile)
+	if err != nil {
+		return "", "", fmt.Errorf("error reading inputSecret file: %w", err)
+	}
+
+	output, warn, err := convertSecretContent(inputFile, bytes, storeType, storeName, creationPolicy, resolver, opts)
+	if err != nil {
+		return "", "", fmt.Errorf("error converting secret: %w", err)
+	}
+	return output, warn, nil
+}
+
+// ConvertSecretContent converts AVP Secrets to ExternalSecrets for the HTTP
+// server, EnvVars are only visible to this conversion.
 func ConvertSecretContent(input []byte, storeType, storeName string,
 	creationPolicy esv1beta1.ExternalSecretCreationPolicy,
 	resolve bool,
 	EnvVars map[string]string) (string, string, error) {
-	output := ""
-	warn := ""
+	return ConvertSecretContentWithOptions(input, storeType, storeName, creationPolicy, resolve, EnvVars, ConvertOptions{})
+}
 
-	if resolve && len(EnvVars) > 0 {
-		for key, value := range EnvVars {
-			_ = os.Setenv(key, value)
-		}
+// ConvertSecretContentWithOptions is ConvertSecretContent with the options of
+// opts, e.g. its output format.
+func ConvertSecretContentWithOptions(input []byte, storeType, storeName string,
+	creationPolicy esv1beta1.ExternalSecretCreationPolicy,
+	resolve bool,
+	EnvVars map[string]string,
+	opts ConvertOptions) (string, string, error) {
+	var resolver Resolver
+	if resolve {
+		resolver = MapResolver(EnvVars)
+	}
+	return convertSecretContent("", input, storeType, storeName, creationPolicy, resolver, opts)
+}
+
+// convertSecretContent converts the secrets of input, file names the input in
+// the positions of errors and warnings. The <% VAR %> placeholders are only
+// resolved when resolver is not nil.
+func convertSecretContent(file string, input []byte, storeType, storeName string,
+	creationPolicy esv1beta1.ExternalSecretCreationPolicy,
+	resolver Resolver, opts ConvertOptions) (string, string, error) {
+	if err := VerifyOutputFormat(opts.Format); err != nil {
+		return "", "", err
 	}
 
-	inputSecretList, err := parseUnstructuredSecret(input)
+	// every document converts to its objects or a warning, unless cached
+	lines := strings.Split(string(input), "\n")
+	docs := splitYAMLDocuments(input)
+	indexes := make([]int, len(docs))
+	for index := range docs {
+		indexes[index] = index
+	}
+	converted, err := convertDocuments(file, lines, docs, indexes, storeType, storeName, creationPolicy, resolver, opts)
 	if err != nil {
-		return "", "", fmt.Errorf("error parsing inputSecret secret: %w", err)
+		return "", "", err
 	}
 
-	for _, inputSecret := range inputSecretList {
-		externalSecret, err := convertSecret2ExtSecret(inputSecret, storeType, storeName, creationPolicy, resolve)
-		if err != nil {
-			switch err.Error() {
-			case fmt.Errorf(ErrCommonNotIncludeAngleBrackets, inputSecret.Name).Error():
-				warn += fmt.Sprintf("Error: %v\n", err)
-				continue
-			case fmt.Errorf(ErrCommonEmptyAnnotations, inputSecret.Name).Error():
-				warn += fmt.Sprintf("Error: %v\n", err)
-				continue
+	warn := ""
+	for _, document := range converted {
+		warn += document.warn
+	}
+	if opts.Format == "" || opts.Format == OutputFormatYAML {
+		var output strings.Builder
+		for _, document := range converted {
+			text, err := document.emitYAML()
+			if err != nil {
+				return "", "", fmt.Errorf(ErrOutputEncode, OutputFormatYAML, err)
 			}
-			return "", "", fmt.Errorf("error converting secret to external secret: %s", err.Error())
+			output.WriteString(text)
 		}
-		yamlData, err := yaml.Marshal(externalSecret)
-		if err != nil {
-			return "", "", fmt.Errorf("error encoding external secret: %w", err)
-		}
-		output += fmt.Sprintf("---\n%s", postProcessOutputES(yamlData))
+		return output.String(), warn, nil
 	}
 
+	var resources []interface{}
+	for _, document := range converted {
+		resources = append(resources, document.resources...)
+	}
+	output, err := formatOutput(resources, opts.Format)
+	if err != nil {
+		return "", "", fmt.Errorf(ErrOutputEncode, opts.Format, err)
+	}
 	return output, warn, nil
 }
 
-func postProcessOutputES(yamlData []byte) string {
-	var externalSecret map[string]interface{}
-	if err := yaml.Unmarshal(yamlData, &externalSecret); err != nil {
-		return string(yamlData)
+// convertDocuments converts the documents of the input lines at indexes, the
+// others stay empty. A document is taken from opts.Cache when it holds it, the
+// strict check and the check for missing values report on all the others at
+// once.
+func convertDocuments(file string, lines []string, docs []yamlDocument, indexes []int, storeType, storeName string,
+	creationPolicy esv1beta1.ExternalSecretCreationPolicy,
+	resolver Resolver, opts ConvertOptions) ([]documentOutput, error) {
+	converted := make([]documentOutput, len(docs))
+	keys := make([]string, len(docs))
+	var inputSecretList []internalSecret
+	for _, index := range indexes {
+		doc := docs[index]
+		if opts.Cache != nil && isSecretDocument(doc) {
+			key, cacheable := opts.Cache.key(file, index, documentText(lines, doc), doc.lines[0],
+				storeType, storeName, creationPolicy, resolver, opts)
+			if cacheable {
+				keys[index] = key
+				if entry, ok := opts.Cache.get(key); ok {
+					for _, object := range entry.Resources {
+						converted[index].resources = append(converted[index].resources, object)
+					}
+					converted[index].warn = entry.Warn
+					converted[index].yaml = entry.YAML
+					continue
+				}
+			}
+		}
+		inputSecret, err := parseSecretDocument(file, lines, index, doc)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing inputSecret secret: %w", err)
+		}
+		if inputSecret != nil {
+			inputSecretList = append(inputSecretList, *inputSecret)
+		}
 	}
 
-	// delete status field
-	delete(externalSecret, "status")
-
-	// delete metadata.creationTimestamp of yamlData
-	if metadata, ok := externalSecret["metadata"].(map[string]interface{}); ok {
-		delete(metadata, "creationTimestamp")
-		externalSecret["metadata"] = metadata
+	if opts.Strict {
+		if err := checkPlaceholders(inputSecretList); err != nil {
+			return nil, err
+		}
+	}
+	if resolver != nil {
+		if err := checkMissingValues(inputSecretList, resolver); err != nil {
+			return nil, err
+		}
 	}
 
-	// delete .spec.target.template.metadata
-	if spec, ok := externalSecret["spec"].(map[string]interface{}); ok {
-		if target, ok := spec["target"].(map[string]interface{}); ok {
-			if template, ok := target["template"].(map[string]interface{}); ok {
-				delete(template, "metadata")
+	for _, inputSecret := range inputSecretList {
+		secretResources, secretWarn, err := convertSecretResources(inputSecret, storeType, storeName, creationPolicy, resolver, opts)
+		if err != nil {
+			return nil, err
+		}
+		index := inputSecret.source.index
+		converted[index] = documentOutput{resources: secretResources, warn: secretWarn}
+		if keys[index] != "" {
+			// the YAML is cached too, emitting it takes most of the time
+			if converted[index].yaml, err = converted[index].emitYAML(); err != nil {
+				return nil, fmt.Errorf(ErrOutputEncode, OutputFormatYAML, err)
+			}
+			if err := opts.Cache.put(keys[index], secretResources, converted[index].yaml, secretWarn); err != nil {
+				return nil, fmt.Errorf("error writing conversion cache: %w", err)
 			}
 		}
 	}
+	return converted, nil
+}
 
-	var needReplace = false
-	if spec, ok := externalSecret["spec"].(map[string]interface{}); ok {
-		if target, ok := spec["target"].(map[string]interface{}); ok {
-			if template, ok := target["template"].(map[string]interface{}); ok {
-				if data, ok := template["data"].(map[string]interface{}); ok {
-					for _, value := range data {
-						if valString, ok := value.(string); ok {
-							// if start with "{{ and end with }}" set a needReplace value
-							if strings.HasPrefix(valString, `"{{`) && strings.HasSuffix(valString, `}}"`) {
-								needReplace = true
-							}
-
-							// "<% ENV %>-{{ .TEST_DIST_LINUX }}"
-							if strings.HasPrefix(valString, `"<`) && strings.HasSuffix(valString, `}}"`) {
-								needReplace = true
-							}
-
-							// "{{ .TEST_DIST_LINUX }}-<% ENV %>"
-							if strings.HasPrefix(valString, `"{{`) && strings.HasSuffix(valString, `>"`) {
-								needReplace = true
-							}
-						}
-					}
-				}
+// documentOutput is what a document of the input converted to, yaml is the
+// emitted resources when known.
+type documentOutput struct {
+	resources []interface{}
+	warn      string
+	yaml      string
+}
+
+func (d documentOutput) emitYAML() (string, error) {
+	if d.yaml != "" {
+		return d.yaml, nil
+	}
+	return formatOutput(d.resources, OutputFormatYAML)
+}
+
+// convertSecretResources returns the objects generated for inputSecret, or a
+// warning when the secret has nothing to convert.
+func convertSecretResources(inputSecret internalSecret, storeType, storeName string,
+	creationPolicy esv1beta1.ExternalSecretCreationPolicy,
+	resolver Resolver, opts ConvertOptions) ([]interface{}, string, error) {
+	externalSecret, err := convertSecret2ExtSecret(inputSecret, storeType, storeName, creationPolicy, resolver)
+	if err != nil {
+		if errors.Is(err, errSecretSkipped) {
+			if pos := inputSecret.documentPosition(); pos.IsValid() {
+				return nil, fmt.Sprintf("Error: %s: %v\n", pos, err), nil
 			}
+			return nil, fmt.Sprintf("Error: %v\n", err), nil
+		}
+		return nil, "", fmt.Errorf("error converting secret to external secret: %w", err)
+	}
+	if opts.ExtractPaths {
+		compactDataFrom(externalSecret)
+	}
+	var trace map[string]string
+	if opts.Trace {
+		trace = traceAnnotations(inputSecret, opts.ToolVersion)
+		annotate(&externalSecret.ObjectMeta, trace)
+	}
+	var resources []interface{}
+	if opts.TemplateFromSize > 0 {
+		if configMap := moveLargeTemplates(externalSecret, opts.TemplateFromSize); configMap != nil {
+			annotate(&configMap.ObjectMeta, trace)
+			resources = append(resources, configMap)
 		}
 	}
+	resources = append(resources, externalSecret)
 
-	newYamlData, err := yaml.Marshal(externalSecret)
+	violations, err := validateResources(resources)
 	if err != nil {
-		panic(err.Error())
+		return nil, "", err
 	}
-	if needReplace {
-		removedLeft := strings.Replace(string(newYamlData), `'"`, `"`, -1)
-		return strings.Replace(removedLeft, `"'`, `"`, -1)
+	if len(violations) > 0 {
+		return nil, "", inputSecret.sourceErrorAt("", "", 0, &SchemaError{Violations: violations})
 	}
-	return string(newYamlData)
+	return resources, "", nil
 }
 
 func convertSecret2ExtSecret(inputSecret internalSecret, storeType, storeName string,
-	createPolicy esv1beta1.ExternalSecretCreationPolicy, resolve bool) (*esv1beta1.ExternalSecret, error) {
+	createPolicy esv1beta1.ExternalSecretCreationPolicy, resolver Resolver) (*esv1beta1.ExternalSecret, error) {
 	if err := secretCommonVerify(inputSecret); err != nil {
 		return nil, err
 	}
@@ -152,31 +283,52 @@ func convertSecret2ExtSecret(inputSecret internalSecret, storeType, storeName st
 	}
 
 	// get the secret of vault path
-	if resolve {
-		var resolvedSecretPath, err = resolved(inputSecret.Annotations["avp.kubernetes.io/path"])
+	if resolver != nil {
+		var resolvedSecretPath, err = resolved(inputSecret.Annotations["avp.kubernetes.io/path"], resolver)
 		if err != nil {
-			return nil, err
+			return nil, inputSecret.sourceError(sourceFieldAnnotations, "avp.kubernetes.io/path", err)
 		}
 		inputSecret.Annotations["avp.kubernetes.io/path"] = resolvedSecretPath
 	}
 
 	switch inputSecret.Type {
 	case corev1.SecretTypeOpaque:
-		return generateEsByOpaqueSecret(&inputSecret, storeType, storeName, createPolicy, resolve)
+		return generateEsByOpaqueSecret(&inputSecret, storeType, storeName, createPolicy, resolver)
 	case corev1.SecretTypeBasicAuth:
-		return generateEsByBasicAuthSecret(&inputSecret, storeType, storeName, createPolicy, resolve)
+		return generateEsByBasicAuthSecret(&inputSecret, storeType, storeName, createPolicy, resolver)
 	case corev1.SecretTypeDockerConfigJson:
-		return generateEsByDockerConfigJSON(&inputSecret, storeType, storeName, createPolicy, resolve)
+		return generateEsByDockerConfigJSON(&inputSecret, storeType, storeName, createPolicy, resolver)
 	case corev1.SecretTypeTLS:
-		return generateEsByTLS(&inputSecret, storeType, storeName, createPolicy, resolve)
+		return generateEsByTLS(&inputSecret, storeType, storeName, createPolicy, resolver)
 	}
 
 	return nil, fmt.Errorf(NotImplSecretType, inputSecret.Type, inputSecret.Name)
 }
 
+// errSecretSkipped matches the errors of the secrets that have nothing to
+// convert, they are skipped with a warning instead.
+var errSecretSkipped = errors.New("secret skipped")
+
+// skippedError is the error of a skipped secret, it is errSecretSkipped.
+type skippedError struct {
+	err error
+}
+
+func (e *skippedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *skippedError) Unwrap() error {
+	return e.err
+}
+
+func (e *skippedError) Is(target error) bool {
+	return target == errSecretSkipped
+}
+
 func secretCommonVerify(inputSecret internalSecret) error {
 	if inputSecret.Annotations == nil {
-		return fmt.Errorf(ErrCommonEmptyAnnotations, inputSecret.Name)
+		return &skippedError{fmt.Errorf(ErrCommonEmptyAnnotations, inputSecret.Name)}
 	}
 	if inputSecret.Annotations["avp.kubernetes.io/path"] == "" {
 		return fmt.Errorf(ErrCommonNotFoundAVPPath, inputSecret.Name)
@@ -190,7 +342,7 @@ func secretCommonVerify(inputSecret internalSecret) error {
 
 	var foundAngleBracketsData = false
 	for _, value := range inputSecret.Data {
-		if captureFromFile.MatchString(value) {
+		if hasPlaceholder(value) {
 			foundAngleBracketsData = true
 			break
 		}
@@ -198,14 +350,14 @@ func secretCommonVerify(inputSecret internalSecret) error {
 
 	var foundAngleBracketsStringData = false
 	for _, value := range inputSecret.StringData {
-		if captureFromFile.MatchString(value) {
+		if hasPlaceholder(value) {
 			foundAngleBracketsStringData = true
 			break
 		}
 	}
 
 	if !foundAngleBracketsData && !foundAngleBracketsStringData {
-		return fmt.Errorf(ErrCommonNotIncludeAngleBrackets, inputSecret.Name)
+		return &skippedError{fmt.Errorf(ErrCommonNotIncludeAngleBrackets, inputSecret.Name)}
 	}
 
 	return nil
@@ -1,8 +1,11 @@
 package converter
 
 import (
+	"errors"
+	"fmt"
+
 	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
-	"os"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 	"testing"
 )
@@ -126,13 +129,12 @@ stringData:
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_ = os.Setenv("ENV", "test")
 			out, err := parseUnstructuredSecret(tt.body)
 			if err != nil {
 				t.Errorf("parseUnstructuredSecret() returned an unexpected error: got: %v", err)
 			}
 			for _, v := range out {
-				externalSecret, err := convertSecret2ExtSecret(v, ClusterSecretStoreType, "test", esv1beta1.CreatePolicyOrphan, true)
+				externalSecret, err := convertSecret2ExtSecret(v, ClusterSecretStoreType, "test", esv1beta1.CreatePolicyOrphan, MapResolver{"ENV": "test"})
 				if err != nil {
 					t.Errorf("convertSecret2ExtSecret() returned an unexpected error: got: %v", err)
 				}
@@ -144,3 +146,78 @@ stringData:
 		})
 	}
 }
+
+func TestUnstructuredSecretBlockScalarNewline(t *testing.T) {
+	doc := `apiVersion: v1
+kind: Secret
+metadata:
+  name: %s
+  annotations:
+    avp.kubernetes.io/path: "secret/data/foo"
+type: Opaque
+stringData:
+  mylogin.conf: |
+    [client]
+    user = <MYSQL_USER>
+`
+	body := []byte(fmt.Sprintf(doc, "first") + "---\n" + fmt.Sprintf(doc, "last"))
+	secrets, err := parseUnstructuredSecret(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(secrets) != 2 {
+		t.Fatalf("expect 2 secrets, got %d", len(secrets))
+	}
+	for _, secret := range secrets {
+		if value := secret.StringData["mylogin.conf"]; value != "[client]\nuser = <MYSQL_USER>\n" {
+			t.Errorf("secret %s: the block scalar lost its trailing newline: %q", secret.Name, value)
+		}
+	}
+}
+
+func TestSecretCommonVerifySkipped(t *testing.T) {
+	tests := []struct {
+		name         string
+		secret       internalSecret
+		expectSkip   bool
+		expectErrMsg string
+	}{
+		{
+			name:         "empty annotations",
+			secret:       internalSecret{ObjectMeta: metav1.ObjectMeta{Name: "app"}},
+			expectSkip:   true,
+			expectErrMsg: fmt.Sprintf(ErrCommonEmptyAnnotations, "app"),
+		},
+		{
+			name: "no placeholders",
+			secret: internalSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "app",
+					Annotations: map[string]string{"avp.kubernetes.io/path": "secret/data/app"},
+				},
+				StringData: map[string]string{"user": "admin"},
+			},
+			expectSkip:   true,
+			expectErrMsg: fmt.Sprintf(ErrCommonNotIncludeAngleBrackets, "app"),
+		},
+		{
+			name: "no path",
+			secret: internalSecret{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Annotations: map[string]string{"team": "a"}},
+			},
+			expectErrMsg: fmt.Sprintf(ErrCommonNotFoundAVPPath, "app"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := secretCommonVerify(tt.secret)
+			if err == nil || err.Error() != tt.expectErrMsg {
+				t.Fatalf("expect error %q, got %v", tt.expectErrMsg, err)
+			}
+			if errors.Is(err, errSecretSkipped) != tt.expectSkip {
+				t.Errorf("expect skipped %v, got %v", tt.expectSkip, !tt.expectSkip)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package converter
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	estemplate "github.com/external-secrets/external-secrets/pkg/template/v2"
+)
+
+// parseTemplate parses text as ESO does, with the function map of its template
+// engine v2.
+func parseTemplate(name, text string) error {
+	_, err := template.New(name).Option("missingkey=error").Funcs(estemplate.FuncMap()).Parse(text)
+	return err
+}
+
+// renderTemplate returns the template of key generated from value, whose
+// placeholders alias turns into SecretKeys, once checkTemplate parsed it.
+func (s *internalSecret) renderTemplate(field, key, value string, alias func(name string) string) (string, error) {
+	return s.checkTemplate(field, key, templateSegments(value, alias))
+}
+
+// checkTemplate joins the segments of the template of key and parses it as ESO
+// does, an error is pinned to the offset templateErrorOffset finds for it.
+func (s *internalSecret) checkTemplate(field, key string, segments []templateSegment) (string, error) {
+	var text strings.Builder
+	for _, segment := range segments {
+		text.WriteString(segment.text)
+	}
+	if err := parseTemplate(key, text.String()); err != nil {
+		return "", s.sourceErrorAt(field, key, templateErrorOffset(key, segments), fmt.Errorf(ErrTemplateParse, err))
+	}
+	return text.String(), nil
+}
+
+// templateErrorOffset returns the offset of the first segment the template
+// stops parsing at, the placeholder that produced a bad expression or the text
+// before it.
+func templateErrorOffset(key string, segments []templateSegment) int {
+	var prefix strings.Builder
+	for _, segment := range segments {
+		prefix.WriteString(segment.text)
+		if parseTemplate(key, prefix.String()) != nil {
+			return segment.offset
+		}
+	}
+	return 0
+}
@@ -0,0 +1,104 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckTemplate(t *testing.T) {
+	tests := []struct {
+		name         string
+		segments     []templateSegment
+		expectErr    string
+		expectOffset int
+	}{
+		{
+			name:     "generated expressions",
+			segments: []templateSegment{{offset: 0, text: "https://"}, {offset: 8, text: "{{ .host }}"}, {offset: 14, text: "/db"}},
+		},
+		{
+			name:     "eso and sprig functions",
+			segments: []templateSegment{{text: `{{ .conf | fromYaml | toYaml }}{{ .name | upper | quote }}{{ "aGVsbG8=" | b64dec }}`}},
+		},
+		{
+			name:     "names that are no identifiers",
+			segments: []templateSegment{{offset: 0, text: `{{ index . "db-user" }}`}, {offset: 9, text: "@"}, {offset: 10, text: `{{ index . "db.host" }}`}},
+		},
+		{
+			name:         "dash in a field name",
+			segments:     []templateSegment{{offset: 0, text: "user = "}, {offset: 7, text: "{{ .db-user }}"}, {offset: 16, text: "\n"}},
+			expectErr:    "bad character",
+			expectOffset: 7,
+		},
+		{
+			name: "unknown function",
+			segments: []templateSegment{{offset: 0, text: "user: "}, {offset: 6, text: "{{ .user }}"}, {offset: 12, text: "\ntoken: "},
+				{offset: 20, text: "{{ .token | env }}"}},
+			expectErr:    `function "env" not defined`,
+			expectOffset: 20,
+		},
+		{
+			name:         "unescaped text before a placeholder",
+			segments:     []templateSegment{{offset: 0, text: "a {{ b "}, {offset: 7, text: "{{ .user }}"}},
+			expectErr:    "generated template does not parse",
+			expectOffset: 0,
+		},
+		{
+			name:         "unclosed action",
+			segments:     []templateSegment{{offset: 0, text: "x"}, {offset: 1, text: "{{ .user "}},
+			expectErr:    "unclosed action",
+			expectOffset: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputSecret := &internalSecret{}
+			inputSecret.Name = "app"
+
+			_, err := inputSecret.checkTemplate(sourceFieldStringData, "conf", tt.segments)
+			if tt.expectErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var srcErr *SourceError
+			if !errors.As(err, &srcErr) {
+				t.Fatalf("expect a source error, got %v", err)
+			}
+			if srcErr.Secret != "app" || srcErr.Key != "conf" {
+				t.Errorf("expect the error pinned to secret app key conf, got secret %s key %s", srcErr.Secret, srcErr.Key)
+			}
+			if !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("expect error containing %q, got %v", tt.expectErr, err)
+			}
+			if offset := templateErrorOffset("conf", tt.segments); offset != tt.expectOffset {
+				t.Errorf("expect the error at offset %d, got %d", tt.expectOffset, offset)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	values := []string{
+		"plain text",
+		"user = <db-user>\npassword = <db.password>",
+		"{{ not a template }} <host>{<port>}",
+		"a < b > c, <none>, literal(<USER>)",
+		"\tindented\n{",
+	}
+
+	for _, value := range values {
+		inputSecret := &internalSecret{}
+		inputSecret.Name = "app"
+		text, err := inputSecret.renderTemplate(sourceFieldStringData, "conf", value, nil)
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", value, err)
+		}
+		if expect := resolveAngleBracketsWith(value, nil); text != expect {
+			t.Errorf("template mismatch for %q: got %q, want %q", value, text, expect)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// templateConfigMapSuffix names the ConfigMap holding the templates of an
+// ExternalSecret after it.
+const templateConfigMapSuffix = "-template"
+
+// moveLargeTemplates moves the multi-line template values of externalSecret
+// larger than size bytes to a ConfigMap, which it returns, and references them
+// from templateFrom. It returns nil when every value stays inline.
+func moveLargeTemplates(externalSecret *esv1beta1.ExternalSecret, size int) *corev1.ConfigMap {
+	template := externalSecret.Spec.Target.Template
+	if template == nil {
+		return nil
+	}
+
+	var keys []string
+	for key, value := range template.Data {
+		if len(value) > size && strings.Contains(value, "\n") {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s%s", externalSecret.Name, templateConfigMapSuffix),
+			Namespace: externalSecret.Namespace,
+			Labels:    externalSecret.Labels,
+		},
+		Data: make(map[string]string),
+	}
+	templateRef := &esv1beta1.TemplateRef{Name: configMap.Name}
+	for _, key := range keys {
+		configMap.Data[key] = template.Data[key]
+		templateRef.Items = append(templateRef.Items, esv1beta1.TemplateRefItem{
+			Key:        key,
+			TemplateAs: esv1beta1.TemplateScopeValues,
+		})
+		delete(template.Data, key)
+	}
+	template.TemplateFrom = append(template.TemplateFrom, esv1beta1.TemplateFrom{
+		ConfigMap: templateRef,
+		Target:    esv1beta1.TemplateTargetData,
+	})
+	return configMap
+}
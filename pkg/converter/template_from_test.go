@@ -0,0 +1,115 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMoveLargeTemplates(t *testing.T) {
+	nginxConf := "server {\n  listen 443;\n  auth_basic_user_file {{ .htpasswd }};\n}\n"
+	tests := []struct {
+		name            string
+		size            int
+		expectConfigMap map[string]string
+		expectInline    []string
+	}{
+		{
+			name:            "large file moved, short value inline",
+			size:            20,
+			expectConfigMap: map[string]string{"nginx.conf": nginxConf},
+			expectInline:    []string{"long-line", "user"},
+		},
+		{
+			name:         "every value below the size",
+			size:         len(nginxConf),
+			expectInline: []string{"long-line", "nginx.conf", "user"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			externalSecret := &esv1beta1.ExternalSecret{
+				Spec: esv1beta1.ExternalSecretSpec{
+					Target: esv1beta1.ExternalSecretTarget{
+						Template: &esv1beta1.ExternalSecretTemplate{
+							Data: map[string]string{
+								"user":       `"{{ .user }}"`,
+								"long-line":  `"{{ .user }}-` + strings.Repeat("x", 64) + `"`,
+								"nginx.conf": nginxConf,
+							},
+						},
+					},
+				},
+			}
+			externalSecret.Name = "nginx"
+			externalSecret.Namespace = "web"
+
+			configMap := moveLargeTemplates(externalSecret, tt.size)
+			template := externalSecret.Spec.Target.Template
+			if diff := cmp.Diff(tt.expectInline, sortedKeys(template.Data)); diff != "" {
+				t.Errorf("inline keys mismatch (-want +got):\n%s", diff)
+			}
+
+			if tt.expectConfigMap == nil {
+				if configMap != nil || len(template.TemplateFrom) != 0 {
+					t.Errorf("expect every value inline, got config map: %v", configMap)
+				}
+				return
+			}
+			if configMap == nil {
+				t.Fatalf("expect a config map")
+			}
+			if configMap.Name != "nginx-template" || configMap.Namespace != "web" {
+				t.Errorf("config map mismatch: got: %s/%s", configMap.Namespace, configMap.Name)
+			}
+			if diff := cmp.Diff(tt.expectConfigMap, configMap.Data); diff != "" {
+				t.Errorf("config map data mismatch (-want +got):\n%s", diff)
+			}
+			expectTemplateFrom := []esv1beta1.TemplateFrom{{
+				ConfigMap: &esv1beta1.TemplateRef{
+					Name:  "nginx-template",
+					Items: []esv1beta1.TemplateRefItem{{Key: "nginx.conf", TemplateAs: esv1beta1.TemplateScopeValues}},
+				},
+				Target: esv1beta1.TemplateTargetData,
+			}}
+			if diff := cmp.Diff(expectTemplateFrom, template.TemplateFrom); diff != "" {
+				t.Errorf("templateFrom mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertWithTemplateFrom(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: nginx
+  annotations:
+    avp.kubernetes.io/path: "secret/data/web"
+type: Opaque
+stringData:
+  user: <user>
+  nginx.conf: |
+    server {
+      auth_basic_user_file <htpasswd>;
+    }
+`)
+	output, _, err := convertSecretContent("", body, SecretStoreType, "test", esv1beta1.CreatePolicyOwner, nil,
+		ConvertOptions{TemplateFromSize: 16})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	docs := strings.Split(output, "---\n")
+	if len(docs) != 3 || !strings.Contains(docs[1], "kind: ConfigMap") || !strings.Contains(docs[2], "kind: ExternalSecret") {
+		t.Fatalf("expect the config map before the external secret, got:\n%s", output)
+	}
+	if !strings.Contains(docs[1], "auth_basic_user_file {{ .htpasswd }};") {
+		t.Errorf("config map should hold the template, got:\n%s", docs[1])
+	}
+	if strings.Contains(docs[2], "auth_basic_user_file") {
+		t.Errorf("external secret should not inline the template, got:\n%s", docs[2])
+	}
+}
@@ -0,0 +1,50 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The annotations tracing a generated object back to its input.
+const (
+	TraceAnnotationSourceFile   = "secret2es.io/source-file"
+	TraceAnnotationSourceIndex  = "secret2es.io/source-index"
+	TraceAnnotationSourceSHA256 = "secret2es.io/source-sha256"
+	TraceAnnotationToolVersion  = "secret2es.io/tool-version"
+)
+
+// traceAnnotations returns the annotations pointing at the document of
+// inputSecret: the input file, the 0-based index of the document in it and
+// the sha256 of the document as written. The empty values are left out.
+func traceAnnotations(inputSecret internalSecret, toolVersion string) map[string]string {
+	annotations := make(map[string]string)
+	if source := inputSecret.source; source != nil {
+		if source.file != "" {
+			annotations[TraceAnnotationSourceFile] = filepath.ToSlash(source.file)
+		}
+		annotations[TraceAnnotationSourceIndex] = strconv.Itoa(source.index)
+		sum := sha256.Sum256([]byte(source.text))
+		annotations[TraceAnnotationSourceSHA256] = hex.EncodeToString(sum[:])
+	}
+	if toolVersion != "" {
+		annotations[TraceAnnotationToolVersion] = toolVersion
+	}
+	return annotations
+}
+
+// annotate adds annotations to the metadata of a generated object.
+func annotate(meta *metav1.ObjectMeta, annotations map[string]string) {
+	if len(annotations) == 0 {
+		return
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = make(map[string]string, len(annotations))
+	}
+	for key, value := range annotations {
+		meta.Annotations[key] = value
+	}
+}
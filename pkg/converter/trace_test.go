@@ -0,0 +1,91 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestTraceAnnotations(t *testing.T) {
+	second := `# the database
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db
+  annotations:
+    avp.kubernetes.io/path: "secret/data/db"
+type: Opaque
+stringData:
+  my.cnf: |
+    [client]
+    user = <user>
+`
+	body := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+---
+` + second)
+	sum := sha256.Sum256([]byte(second[len("# the database\n"):]))
+
+	tests := []struct {
+		name   string
+		file   string
+		opts   ConvertOptions
+		expect map[string]string
+	}{
+		{
+			name: "disabled",
+			file: "secrets/db.yaml",
+		},
+		{
+			name: "file and version",
+			file: "secrets/db.yaml",
+			opts: ConvertOptions{Trace: true, ToolVersion: "v1.2.3"},
+			expect: map[string]string{
+				TraceAnnotationSourceFile:   "secrets/db.yaml",
+				TraceAnnotationSourceIndex:  "1",
+				TraceAnnotationSourceSHA256: hex.EncodeToString(sum[:]),
+				TraceAnnotationToolVersion:  "v1.2.3",
+			},
+		},
+		{
+			name: "content without file and version",
+			opts: ConvertOptions{Trace: true},
+			expect: map[string]string{
+				TraceAnnotationSourceIndex:  "1",
+				TraceAnnotationSourceSHA256: hex.EncodeToString(sum[:]),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Format = OutputFormatList
+			tt.opts.TemplateFromSize = 1
+			output, _, err := convertSecretContent(tt.file, body, SecretStoreType, "test", esv1beta1.CreatePolicyOwner, nil, tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var list struct {
+				Items []corev1.ConfigMap `json:"items"`
+			}
+			if err := yaml.Unmarshal([]byte(output), &list); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(list.Items) != 2 {
+				t.Fatalf("expect a config map and an external secret, got:\n%s", output)
+			}
+			for _, item := range list.Items {
+				if diff := cmp.Diff(tt.expect, item.Annotations); diff != "" {
+					t.Errorf("annotations of %s mismatch (-want +got):\n%s", item.Kind, diff)
+				}
+			}
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"os"
-	"regexp"
 	"runtime/debug"
 	"sigs.k8s.io/yaml"
 	"strings"
@@ -54,16 +53,50 @@ type internalSecret struct {
 	// More info: https://kubernetes.io/docs/concepts/configuration/secret/#secret-types
 	// +optional
 	Type corev1.SecretType `json:"type,omitempty" protobuf:"bytes,3,opt,name=type,casttype=SecretType"`
+
+	// source locates the secret in the input, nil when not parsed from one.
+	source *secretSource
+	// rules records the conversion rule of every key, nil unless explaining.
+	rules map[string]string
+}
+
+// yamlDocument is a document of the input with comments removed, lines maps
+// each of its lines back to the 1-based line of the original input.
+type yamlDocument struct {
+	content string
+	lines   []int
 }
 
-func splitYAMLDocuments(fileBody []byte) []string {
-	re := regexp.MustCompile(`(?m)^---$`)
-	fileBodyWithoutCommented := processCommented(fileBody)
-	potentialDocs := re.Split(string(fileBodyWithoutCommented), -1)
-	return potentialDocs
+// splitYAMLDocuments splits the input on `---` after removing comments, keeping
+// track of the original line numbers of every document. A document keeps the
+// newline before the `---` ending it, so its last block scalar keeps it too.
+func splitYAMLDocuments(fileBody []byte) []yamlDocument {
+	stripped, lineMap := stripComments(fileBody)
+	var docs []yamlDocument
+	current := yamlDocument{}
+	var content []string
+	for idx, line := range strings.Split(string(stripped), "\n") {
+		if line == "---" {
+			current.content = strings.Join(append(content, ""), "\n")
+			docs = append(docs, current)
+			current = yamlDocument{}
+			content = nil
+			continue
+		}
+		content = append(content, line)
+		current.lines = append(current.lines, lineMap[idx])
+	}
+	current.content = strings.Join(content, "\n")
+	return append(docs, current)
 }
 
 func parseUnstructuredSecret(body []byte) ([]internalSecret, error) {
+	return parseUnstructuredSecretFile("", body)
+}
+
+// parseUnstructuredSecretFile parses the secrets of body, recording their
+// positions in the named file for diagnostics.
+func parseUnstructuredSecretFile(file string, body []byte) ([]internalSecret, error) {
 	defer func() {
 		if r := recover(); r != nil {
 			_, _ = fmt.Fprintf(os.Stderr, "Panic occurred: %v\n", r)
@@ -72,17 +105,37 @@ func parseUnstructuredSecret(body []byte) ([]internalSecret, error) {
 		}
 	}()
 
+	lines := strings.Split(string(body), "\n")
 	var secrets []internalSecret
-	for _, yamlContent := range splitYAMLDocuments(body) {
-		if !strings.Contains(yamlContent, "kind: Secret") {
-			continue
+	for index, doc := range splitYAMLDocuments(body) {
+		inputSecret, err := parseSecretDocument(file, lines, index, doc)
+		if err != nil {
+			return nil, err
 		}
-		inputSecret := &internalSecret{}
-		if err := yaml.Unmarshal([]byte(yamlContent), &inputSecret); err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "yaml content: %s\n", yamlContent)
-			return nil, fmt.Errorf("error unmarshalling inputSecret secret: %w", err)
+		if inputSecret != nil {
+			secrets = append(secrets, *inputSecret)
 		}
-		secrets = append(secrets, *inputSecret)
 	}
 	return secrets, nil
 }
+
+// isSecretDocument reports whether doc may hold a secret.
+func isSecretDocument(doc yamlDocument) bool {
+	return strings.Contains(doc.content, "kind: Secret")
+}
+
+// parseSecretDocument parses the secret of the document at index of the input
+// lines, nil when the document holds no secret.
+func parseSecretDocument(file string, lines []string, index int, doc yamlDocument) (*internalSecret, error) {
+	if !isSecretDocument(doc) {
+		return nil, nil
+	}
+	inputSecret := &internalSecret{}
+	if err := yaml.Unmarshal([]byte(doc.content), &inputSecret); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "yaml content: %s\n", doc.content)
+		return nil, fmt.Errorf("error unmarshalling inputSecret secret: %w", err)
+	}
+	inputSecret.source = locateSecret(file, lines, doc)
+	inputSecret.source.index = index
+	return inputSecret, nil
+}
@@ -0,0 +1,195 @@
+package converter
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	openapierrors "k8s.io/kube-openapi/pkg/validation/errors"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+	"sigs.k8s.io/yaml"
+)
+
+// externalSecretCRD is the ExternalSecret CRD of the ESO release the
+// converter is built against.
+//
+//go:embed schema/external-secrets.io_externalsecrets.yaml
+var externalSecretCRD []byte
+
+var (
+	externalSecretSchemasOnce sync.Once
+	externalSecretSchemas     map[string]*validate.SchemaValidator
+	externalSecretSchemasErr  error
+)
+
+// SchemaViolation is a field of a generated object the ESO CRD or the
+// Kubernetes name and key rules reject.
+type SchemaViolation struct {
+	// Object is the kind, namespace and name of the object.
+	Object  string
+	Field   string
+	Message string
+}
+
+func (v SchemaViolation) String() string {
+	return fmt.Sprintf("%s %s: %s", v.Object, v.Field, v.Message)
+}
+
+// SchemaError lists the violations of the objects generated for a secret.
+type SchemaError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, "\n  "+violation.String())
+	}
+	return fmt.Sprintf(ErrSchemaViolations, strings.Join(messages, ""))
+}
+
+// loadExternalSecretSchemas returns a validator per version of the embedded
+// CRD, by apiVersion.
+func loadExternalSecretSchemas() (map[string]*validate.SchemaValidator, error) {
+	externalSecretSchemasOnce.Do(func() {
+		body, err := yaml.YAMLToJSON(externalSecretCRD)
+		if err != nil {
+			externalSecretSchemasErr = fmt.Errorf("error parsing the ExternalSecret CRD: %w", err)
+			return
+		}
+		var crd struct {
+			Spec struct {
+				Group    string `json:"group"`
+				Versions []struct {
+					Name   string `json:"name"`
+					Schema struct {
+						OpenAPIV3Schema json.RawMessage `json:"openAPIV3Schema"`
+					} `json:"schema"`
+				} `json:"versions"`
+			} `json:"spec"`
+		}
+		if err := json.Unmarshal(body, &crd); err != nil {
+			externalSecretSchemasErr = fmt.Errorf("error parsing the ExternalSecret CRD: %w", err)
+			return
+		}
+		externalSecretSchemas = make(map[string]*validate.SchemaValidator)
+		for _, version := range crd.Spec.Versions {
+			schema := &spec.Schema{}
+			if err := json.Unmarshal(version.Schema.OpenAPIV3Schema, schema); err != nil {
+				externalSecretSchemasErr = fmt.Errorf("error parsing the ExternalSecret CRD: %w", err)
+				return
+			}
+			apiVersion := crd.Spec.Group + "/" + version.Name
+			externalSecretSchemas[apiVersion] = validate.NewSchemaValidator(schema, nil, "", strfmt.Default)
+		}
+	})
+	return externalSecretSchemas, externalSecretSchemasErr
+}
+
+// validateResources checks the generated objects against the ESO CRD and the
+// Kubernetes rules for names and keys.
+func validateResources(resources []interface{}) ([]SchemaViolation, error) {
+	schemas, err := loadExternalSecretSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []SchemaViolation
+	for _, resource := range resources {
+		object, err := resourceMap(resource)
+		if err != nil {
+			return nil, err
+		}
+		name := objectName(object)
+		add := func(field string, messages ...string) {
+			for _, message := range messages {
+				violations = append(violations, SchemaViolation{Object: name, Field: field, Message: message})
+			}
+		}
+
+		metadata, _ := object["metadata"].(map[string]interface{})
+		objectNameValue, _ := metadata["name"].(string)
+		add("metadata.name", k8svalidation.IsDNS1123Subdomain(objectNameValue)...)
+		if namespace, ok := metadata["namespace"].(string); ok && namespace != "" {
+			add("metadata.namespace", k8svalidation.IsDNS1123Label(namespace)...)
+		}
+
+		switch object["kind"] {
+		case "ConfigMap":
+			data, _ := object["data"].(map[string]interface{})
+			for _, key := range sortedFields(data) {
+				add("data."+key, k8svalidation.IsConfigMapKey(key)...)
+			}
+		case "ExternalSecret":
+			apiVersion, _ := object["apiVersion"].(string)
+			validator, ok := schemas[apiVersion]
+			if !ok {
+				add("apiVersion", fmt.Sprintf("unknown ExternalSecret version %s", apiVersion))
+				continue
+			}
+			for _, err := range validator.Validate(object).Errors {
+				field := ""
+				if validation, ok := err.(*openapierrors.Validation); ok {
+					field = validation.Name
+				}
+				add(field, err.Error())
+			}
+			violations = append(violations, externalSecretViolations(name, object)...)
+		}
+	}
+	return violations, nil
+}
+
+// externalSecretViolations checks the rules of an ExternalSecret the CRD does
+// not: the names and keys of the target secret and the remote properties.
+func externalSecretViolations(name string, object map[string]interface{}) []SchemaViolation {
+	var violations []SchemaViolation
+	add := func(field string, messages ...string) {
+		for _, message := range messages {
+			violations = append(violations, SchemaViolation{Object: name, Field: field, Message: message})
+		}
+	}
+
+	spec, _ := object["spec"].(map[string]interface{})
+	target, _ := spec["target"].(map[string]interface{})
+	if targetName, ok := target["name"].(string); ok && targetName != "" {
+		add("spec.target.name", k8svalidation.IsDNS1123Subdomain(targetName)...)
+	}
+	template, _ := target["template"].(map[string]interface{})
+	templateData, _ := template["data"].(map[string]interface{})
+	for _, key := range sortedFields(templateData) {
+		add("spec.target.template.data."+key, k8svalidation.IsConfigMapKey(key)...)
+	}
+
+	data, _ := spec["data"].([]interface{})
+	for i, item := range data {
+		entry, _ := item.(map[string]interface{})
+		field := fmt.Sprintf("spec.data[%d]", i)
+		if secretKey, _ := entry["secretKey"].(string); secretKey == "" {
+			add(field+".secretKey", "must not be empty")
+		}
+		remoteRef, _ := entry["remoteRef"].(map[string]interface{})
+		if key, _ := remoteRef["key"].(string); key == "" {
+			add(field+".remoteRef.key", "must not be empty")
+		}
+		if property, _ := remoteRef["property"].(string); strings.TrimSpace(property) == "" {
+			add(field+".remoteRef.property", "must not be empty")
+		}
+	}
+	return violations
+}
+
+func sortedFields(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,131 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateResources(t *testing.T) {
+	newExternalSecret := func() *esv1beta1.ExternalSecret {
+		return &esv1beta1.ExternalSecret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "external-secrets.io/v1beta1", Kind: "ExternalSecret"},
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "team"},
+			Spec: esv1beta1.ExternalSecretSpec{
+				SecretStoreRef: esv1beta1.SecretStoreRef{Name: "vault", Kind: SecretStoreType},
+				Target: esv1beta1.ExternalSecretTarget{
+					Name:           "app",
+					CreationPolicy: esv1beta1.CreatePolicyOwner,
+					Template: &esv1beta1.ExternalSecretTemplate{
+						Data: map[string]string{"user": "{{ .user }}"},
+					},
+				},
+				Data: []esv1beta1.ExternalSecretData{{
+					SecretKey: "user",
+					RemoteRef: esv1beta1.ExternalSecretDataRemoteRef{Key: "app", Property: "user"},
+				}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(es *esv1beta1.ExternalSecret, cm *corev1.ConfigMap)
+		expect []string
+	}{
+		{
+			name:   "valid",
+			mutate: func(es *esv1beta1.ExternalSecret, cm *corev1.ConfigMap) {},
+		},
+		{
+			name: "names",
+			mutate: func(es *esv1beta1.ExternalSecret, cm *corev1.ConfigMap) {
+				es.Name = "App_1"
+				es.Namespace = "team.a"
+				es.Spec.Target.Name = "App_1"
+			},
+			expect: []string{
+				"ExternalSecret team.a/App_1 metadata.name",
+				"ExternalSecret team.a/App_1 metadata.namespace",
+				"ExternalSecret team.a/App_1 spec.target.name",
+			},
+		},
+		{
+			name: "keys",
+			mutate: func(es *esv1beta1.ExternalSecret, cm *corev1.ConfigMap) {
+				es.Spec.Target.Template.Data["my key"] = "{{ .user }}"
+				cm.Data["bad/key"] = "value"
+			},
+			expect: []string{
+				"ConfigMap team/app-templates data.bad/key",
+				"ExternalSecret team/app spec.target.template.data.my key",
+			},
+		},
+		{
+			name: "empty property",
+			mutate: func(es *esv1beta1.ExternalSecret, cm *corev1.ConfigMap) {
+				es.Spec.Data[0].RemoteRef.Property = " "
+			},
+			expect: []string{"ExternalSecret team/app spec.data[0].remoteRef.property"},
+		},
+		{
+			name: "crd schema",
+			mutate: func(es *esv1beta1.ExternalSecret, cm *corev1.ConfigMap) {
+				es.Spec.Target.CreationPolicy = "Always"
+			},
+			expect: []string{"ExternalSecret team/app spec.target.creationPolicy"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es := newExternalSecret()
+			cm := &corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{Name: "app-templates", Namespace: "team"},
+				Data:       map[string]string{"user": "{{ .user }}"},
+			}
+			tt.mutate(es, cm)
+			violations, err := validateResources([]interface{}{cm, es})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, violation := range violations {
+				got = append(got, violation.Object+" "+violation.Field)
+			}
+			if diff := cmp.Diff(tt.expect, got); diff != "" {
+				t.Errorf("violations mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConvertSchemaError(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: Bad_Name
+  annotations:
+    avp.kubernetes.io/path: "secret/data/app"
+type: Opaque
+stringData:
+  user: <user>
+`)
+	_, _, err := convertSecretContent("secrets.yaml", body, SecretStoreType, "vault", esv1beta1.CreatePolicyOwner, nil, ConvertOptions{})
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expect a schema error, got %v", err)
+	}
+	if len(schemaErr.Violations) != 2 || schemaErr.Violations[0].Field != "metadata.name" {
+		t.Errorf("unexpected violations %+v", schemaErr.Violations)
+	}
+	if !strings.Contains(err.Error(), "secrets.yaml:4:9: secret Bad_Name") {
+		t.Errorf("expect the error pinned to the secret, got %v", err)
+	}
+}
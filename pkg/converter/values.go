@@ -0,0 +1,188 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const (
+	ValueSourceFlags       = "--set"
+	ValueSourceEnvironment = "environment"
+)
+
+// ValueSource is a named set of values for <% VAR %> placeholders.
+type ValueSource struct {
+	Name   string
+	Values map[string]string
+}
+
+// LayeredResolver resolves placeholders from several sources, the first source
+// defining a variable wins. It remembers which source every looked up variable
+// was resolved from.
+type LayeredResolver struct {
+	sources []ValueSource
+
+	mu      sync.Mutex
+	origins map[string]string
+}
+
+// NewLayeredResolver returns a resolver over sources, highest precedence first.
+func NewLayeredResolver(sources ...ValueSource) *LayeredResolver {
+	return &LayeredResolver{
+		sources: sources,
+		origins: make(map[string]string),
+	}
+}
+
+func (l *LayeredResolver) Lookup(name string) (string, bool) {
+	for _, source := range l.sources {
+		if value, ok := source.Values[name]; ok {
+			l.mu.Lock()
+			l.origins[name] = source.Name
+			l.mu.Unlock()
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// ValueOrigin is the source a variable was resolved from.
+type ValueOrigin struct {
+	Name   string
+	Source string
+}
+
+// Origins returns the looked up variables with the source they came from,
+// sorted by variable name.
+func (l *LayeredResolver) Origins() []ValueOrigin {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var origins []ValueOrigin
+	for name, source := range l.origins {
+		origins = append(origins, ValueOrigin{Name: name, Source: source})
+	}
+	sort.Slice(origins, func(i, j int) bool {
+		return origins[i].Name < origins[j].Name
+	})
+	return origins
+}
+
+// ParseSetValues parses KEY=VALUE pairs given with --set, later pairs override
+// earlier ones.
+func ParseSetValues(pairs []string) (ValueSource, error) {
+	source := ValueSource{Name: ValueSourceFlags, Values: make(map[string]string)}
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return ValueSource{}, fmt.Errorf(ErrValuesIllegalSet, pair)
+		}
+		source.Values[key] = value
+	}
+	return source, nil
+}
+
+// EnvironmentValues snapshots the process environment as a value source.
+func EnvironmentValues() ValueSource {
+	source := ValueSource{Name: ValueSourceEnvironment, Values: make(map[string]string)}
+	for _, env := range os.Environ() {
+		if key, value, ok := strings.Cut(env, "="); ok {
+			source.Values[key] = value
+		}
+	}
+	return source
+}
+
+// LoadValuesFile reads a .env, YAML or JSON values file.
+func LoadValuesFile(path string) (ValueSource, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return ValueSource{}, fmt.Errorf("error reading values file: %w", err)
+	}
+
+	var values map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".env":
+		values, err = parseDotEnv(path, body)
+	case ".yaml", ".yml", ".json":
+		values, err = parseValuesYAML(path, body)
+	default:
+		if filepath.Base(path) == ".env" {
+			values, err = parseDotEnv(path, body)
+		} else {
+			err = fmt.Errorf(ErrValuesUnsupportedFile, path)
+		}
+	}
+	if err != nil {
+		return ValueSource{}, err
+	}
+	return ValueSource{Name: path, Values: values}, nil
+}
+
+func parseDotEnv(path string, body []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf(ErrValuesIllegalLine, lineNumber, path)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading values file: %w", err)
+	}
+	return values, nil
+}
+
+// parseValuesYAML keeps scalars as written, so 1.10 or 0777 are not
+// reformatted as numbers.
+func parseValuesYAML(path string, body []byte) (map[string]string, error) {
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(body, &root); err != nil {
+		return nil, fmt.Errorf("error parsing values file %s: %w", path, err)
+	}
+	if len(root.Content) == 0 {
+		return make(map[string]string), nil
+	}
+	return valuesFromMapping(path, root.Content[0])
+}
+
+func valuesFromMapping(path string, mapping *yamlv3.Node) (map[string]string, error) {
+	if mapping.Kind != yamlv3.MappingNode {
+		return nil, fmt.Errorf(ErrValuesNotFlatMap, path)
+	}
+	values := make(map[string]string)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if value.Kind != yamlv3.ScalarNode {
+			return nil, fmt.Errorf(ErrValuesNotScalar, key.Value, path)
+		}
+		if value.Tag == "!!null" {
+			continue
+		}
+		values[key.Value] = value.Value
+	}
+	return values, nil
+}
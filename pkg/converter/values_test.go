@@ -0,0 +1,187 @@
+package converter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	esv1beta1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1beta1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadValuesFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		body   string
+		expect map[string]string
+		err    func(path string) error
+	}{
+		{
+			name: "dot env file",
+			file: "dev.env",
+			body: `# comment
+ENV=dev
+export REGION = eu-west-1
+QUOTED="with spaces"
+SINGLE='single'
+`,
+			expect: map[string]string{
+				"ENV":    "dev",
+				"REGION": "eu-west-1",
+				"QUOTED": "with spaces",
+				"SINGLE": "single",
+			},
+		},
+		{
+			name: "illegal dot env line",
+			file: "bad.env",
+			body: "ENV=dev\nREGION\n",
+			err: func(path string) error {
+				return fmt.Errorf(ErrValuesIllegalLine, 2, path)
+			},
+		},
+		{
+			name: "yaml keeps scalars as written",
+			file: "values.yaml",
+			body: `ENV: prod
+VERSION: 1.10
+MODE: 0777
+ENABLED: yes
+UNSET: ~
+`,
+			expect: map[string]string{
+				"ENV":     "prod",
+				"VERSION": "1.10",
+				"MODE":    "0777",
+				"ENABLED": "yes",
+			},
+		},
+		{
+			name:   "json file",
+			file:   "values.json",
+			body:   `{"ENV": "stage", "PORT": 8080}`,
+			expect: map[string]string{"ENV": "stage", "PORT": "8080"},
+		},
+		{
+			name: "nested yaml is not supported",
+			file: "nested.yaml",
+			body: "ENV:\n  name: dev\n",
+			err: func(path string) error {
+				return fmt.Errorf(ErrValuesNotScalar, "ENV", path)
+			},
+		},
+		{
+			name: "unsupported extension",
+			file: "values.toml",
+			body: `ENV = "dev"`,
+			err: func(path string) error {
+				return fmt.Errorf(ErrValuesUnsupportedFile, path)
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.body), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			source, err := LoadValuesFile(path)
+			if tt.err != nil {
+				if err == nil || err.Error() != tt.err(path).Error() {
+					t.Errorf("LoadValuesFile() error mismatch: got: %v, want: %v", err, tt.err(path))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if source.Name != path {
+				t.Errorf("source name mismatch: got: %s, want: %s", source.Name, path)
+			}
+			if diff := cmp.Diff(tt.expect, source.Values); diff != "" {
+				t.Errorf("values mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLayeredResolverPrecedence(t *testing.T) {
+	set, err := ParseSetValues([]string{"ENV=flag", "EMPTY="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := NewLayeredResolver(
+		set,
+		ValueSource{Name: "prod.env", Values: map[string]string{"ENV": "file", "REGION": "eu-west-1"}},
+		ValueSource{Name: ValueSourceEnvironment, Values: map[string]string{"ENV": "env", "REGION": "us-east-1", "TENANT": "a"}},
+	)
+
+	out, err := resolved("<% ENV %>/<% REGION %>/<% TENANT %>", resolver)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "flag/eu-west-1/a" {
+		t.Errorf("resolved() returned an unexpected string: got: %s, want: %s", out, "flag/eu-west-1/a")
+	}
+
+	expect := []ValueOrigin{
+		{Name: "ENV", Source: ValueSourceFlags},
+		{Name: "REGION", Source: "prod.env"},
+		{Name: "TENANT", Source: ValueSourceEnvironment},
+	}
+	if diff := cmp.Diff(expect, resolver.Origins()); diff != "" {
+		t.Errorf("origins mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParseSetValues([]string{"=value"}); err == nil ||
+		err.Error() != fmt.Errorf(ErrValuesIllegalSet, "=value").Error() {
+		t.Errorf("ParseSetValues() should reject a pair without key, got: %v", err)
+	}
+}
+
+func TestMissingValuesListedAtOnce(t *testing.T) {
+	body := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: first
+  annotations:
+    avp.kubernetes.io/path: "secret/data/<% ENV %>/<% REGION %>"
+type: Opaque
+stringData:
+  user: <USER>
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: second
+  annotations:
+    avp.kubernetes.io/path: "secret/data/app"
+type: Opaque
+stringData:
+  tenant: <% TENANT %>-<USER>
+  region: <% REGION %>
+`)
+	_, _, err := convertSecretContent("input.yaml", body, SecretStoreType, "test", esv1beta1.CreatePolicyOwner,
+		MapResolver{"ENV": "dev"}, ConvertOptions{})
+	var missing *MissingValuesError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expect a MissingValuesError, got: %v", err)
+	}
+	if diff := cmp.Diff([]string{"REGION", "TENANT"}, missing.Names); diff != "" {
+		t.Errorf("missing names mismatch (-want +got):\n%s", diff)
+	}
+
+	var positions []string
+	for _, occurrence := range missing.Occurrences {
+		positions = append(positions, occurrence.Pos.String())
+	}
+	expect := []string{"input.yaml:6:52", "input.yaml:20:11", "input.yaml:19:11"}
+	if diff := cmp.Diff(expect, positions); diff != "" {
+		t.Errorf("occurrence positions mismatch (-want +got):\n%s", diff)
+	}
+}
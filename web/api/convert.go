@@ -26,6 +26,7 @@ type ConvertRequest struct {
 	CreationPolicy string            `json:"creationPolicy"`
 	Resolve        bool              `json:"resolve"`
 	EnvVars        map[string]string `json:"envVars,omitempty"`
+	Format         string            `json:"format,omitempty"`
 }
 
 func Handler(w http.ResponseWriter, r *http.Request) {
@@ -64,6 +65,16 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := converter.VerifyOutputFormat(request.Format); err != nil {
+		errorResponse := map[string]string{
+			"error": err.Error(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(errorResponse)
+		return
+	}
+
 	if request.Resolve && len(request.EnvVars) == 0 {
 		errorResponse := map[string]string{
 			"error": "Resolve is set to true but no environment variables provided",
@@ -74,13 +85,14 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, warn, err := converter.ConvertSecretContent(
+	result, warn, err := converter.ConvertSecretContentWithOptions(
 		[]byte(request.Content),
 		request.StoreType,
 		request.StoreName,
 		esv1beta1.ExternalSecretCreationPolicy(request.CreationPolicy),
 		request.Resolve,
 		request.EnvVars,
+		converter.ConvertOptions{Format: request.Format},
 	)
 
 	if err != nil {